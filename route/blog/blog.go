@@ -3,16 +3,20 @@
 package blog
 
 import (
+  "bytes"
   "context"
   "database/sql"
-  "encoding/json"
   "fmt"
-  "io/ioutil"
+  "github.com/microcosm-cc/bluemonday"
+  "github.com/yuin/goldmark"
   "micrified.com/internal/user"
   "micrified.com/route"
   "micrified.com/service/auth"
   "net/http"
+  "net/url"
+  "regexp"
   "strconv"
+  "strings"
   "time"
 )
 
@@ -27,6 +31,22 @@ import (
 const (
   RouteName string     = "blog"
   RouteListName string = "blogs"
+  RouteTagsName string = "tags"
+
+  // SlugMaxLength caps how much of the title is kept when deriving a slug
+  SlugMaxLength int = 80
+
+  // DescriptionMaxLength caps how much of the body is kept when deriving
+  // a description automatically
+  DescriptionMaxLength int = 200
+)
+
+// Content type a blog post's body is written in. ContentTypeMarkdown is
+// the default, and the only one rendered server-side into BodyHTML.
+const (
+  ContentTypeMarkdown string = "markdown"
+  ContentTypeHTML     string = "html"
+  ContentTypeText     string = "text"
 )
 
 
@@ -39,7 +59,7 @@ const (
 
 // Data: Blog
 type blogDataType struct {
-  TimeFormat, IndexTable, ContentTable string
+  TimeFormat, IndexTable, ContentTable, TagTable, TagJoinTable, RevisionTable string
 }
 
 // Controller: Blog
@@ -48,6 +68,12 @@ type Controller route.ControllerType[blogDataType]
 // ListController: Blog
 type ListController route.ControllerType[blogDataType]
 
+// TagsController: Blog
+type TagsController route.ControllerType[blogDataType]
+
+// RevisionsController: Blog
+type RevisionsController route.ControllerType[blogDataType]
+
 
 /*\
  *******************************************************************************
@@ -57,9 +83,12 @@ type ListController route.ControllerType[blogDataType]
 
 
 var blogData blogDataType = blogDataType {
-  TimeFormat:   "2006-01-02 15:04:05",
-  IndexTable:   "blog_pages",
-  ContentTable: "page_content",
+  TimeFormat:    "2006-01-02 15:04:05",
+  IndexTable:    "blog_pages",
+  ContentTable:  "page_content",
+  TagTable:      "blog_tags",
+  TagJoinTable:  "blog_page_tags",
+  RevisionTable: "page_content_revisions",
 }
 
 
@@ -83,6 +112,12 @@ func NewListController (s route.Service) ListController {
 }
 
 func NewController (s route.Service) Controller {
+  ensureSlugColumn(s)
+  ensureTagTables(s)
+  ensureDeletedAtColumns(s)
+  ensureRevisionsTable(s)
+  ensureMetadataColumns(s)
+
   return Controller {
     Name:                RouteName,
     Methods: map[string]route.Method {
@@ -97,6 +132,252 @@ func NewController (s route.Service) Controller {
   }
 }
 
+func NewTagsController (s route.Service) TagsController {
+  return TagsController {
+    Name: RouteTagsName,
+    Methods: map[string]route.Method {
+      http.MethodGet: route.Restful.Get,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data:    blogData,
+  }
+}
+
+func NewRevisionsController (s route.Service) RevisionsController {
+  return RevisionsController {
+    Name:    RouteName,
+    Methods: map[string]route.Method {
+      http.MethodGet: route.Restful.Get,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data:    blogData,
+  }
+}
+
+// ensureSlugColumn adds the slug column the first time this controller is
+// constructed against a database that predates slug-based routing; it is a
+// no-op on every startup after that.
+func ensureSlugColumn (s route.Service) {
+  q := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS slug VARCHAR(%d)",
+    blogData.IndexTable, SlugMaxLength)
+  s.Database.DB.Exec(q)
+}
+
+// ensureTagTables creates the blog_tags/blog_page_tags tables the first
+// time this controller is constructed, then migrates any legacy
+// comma-separated values sitting in the old free-text "tag" column into
+// them. Both steps are no-ops on every startup after the first.
+func ensureTagTables (s route.Service) {
+  s.Database.DB.Exec(fmt.Sprintf(
+    "CREATE TABLE IF NOT EXISTS %s (id INT AUTO_INCREMENT PRIMARY KEY, " +
+    "name VARCHAR(80) UNIQUE NOT NULL)", blogData.TagTable))
+  s.Database.DB.Exec(fmt.Sprintf(
+    "CREATE TABLE IF NOT EXISTS %s (page_id INT NOT NULL, tag_id INT NOT NULL, " +
+    "PRIMARY KEY (page_id, tag_id))", blogData.TagJoinTable))
+
+  // Legacy rows: a non-empty "tag" column that has not yet been migrated
+  // into blog_page_tags
+  q := fmt.Sprintf("SELECT a.id, a.tag FROM %s AS a " +
+                   "LEFT JOIN %s AS b ON b.page_id = a.id " +
+                   "WHERE a.tag IS NOT NULL AND a.tag != '' AND b.page_id IS NULL",
+                   blogData.IndexTable, blogData.TagJoinTable)
+  rows, err := s.Database.DB.Query(q)
+  if nil != err {
+    return
+  }
+  defer rows.Close()
+
+  type legacyTag struct {
+    pageID int64
+    tag    string
+  }
+  var legacy []legacyTag
+  for rows.Next() {
+    var entry legacyTag
+    if err = rows.Scan(&entry.pageID, &entry.tag); nil == err {
+      legacy = append(legacy, entry)
+    }
+  }
+
+  for _, entry := range legacy {
+    for _, name := range normalizeTags(strings.Split(entry.tag, ",")) {
+      tagID, err := upsertTagID(s.Database.Context, s.Database.DB, name)
+      if nil != err {
+        continue
+      }
+      s.Database.DB.Exec(fmt.Sprintf("INSERT IGNORE INTO %s (page_id,tag_id) VALUES (?,?)",
+        blogData.TagJoinTable), entry.pageID, tagID)
+    }
+  }
+}
+
+// ensureDeletedAtColumns adds the soft-delete columns the first time this
+// controller is constructed against a database that predates it; it is a
+// no-op on every startup after that.
+func ensureDeletedAtColumns (s route.Service) {
+  s.Database.DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS deleted_at DATETIME NULL",
+    blogData.IndexTable))
+  s.Database.DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS deleted_at DATETIME NULL",
+    blogData.ContentTable))
+}
+
+// ensureRevisionsTable creates the table that archives the title/subtitle/
+// body superseded by each edit; it is a no-op on every startup after the
+// first.
+func ensureRevisionsTable (s route.Service) {
+  q := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (" +
+                   "page_id INT NOT NULL, revision_no INT NOT NULL, " +
+                   "title VARCHAR(255) NOT NULL, subtitle VARCHAR(255) NOT NULL, " +
+                   "body LONGTEXT NOT NULL, edited_at DATETIME NOT NULL, " +
+                   "edited_by VARCHAR(255) NOT NULL, " +
+                   "PRIMARY KEY (page_id, revision_no))", blogData.RevisionTable)
+  s.Database.DB.Exec(q)
+}
+
+// ensureMetadataColumns adds the author/thumbnail/description/content-type
+// columns the first time this controller is constructed against a database
+// that predates them; it is a no-op on every startup after that.
+func ensureMetadataColumns (s route.Service) {
+  s.Database.DB.Exec(fmt.Sprintf(
+    "ALTER TABLE %s ADD COLUMN IF NOT EXISTS author_name VARCHAR(200) NOT NULL DEFAULT ''",
+    blogData.IndexTable))
+  s.Database.DB.Exec(fmt.Sprintf(
+    "ALTER TABLE %s ADD COLUMN IF NOT EXISTS author_image_url VARCHAR(500) NOT NULL DEFAULT ''",
+    blogData.IndexTable))
+  s.Database.DB.Exec(fmt.Sprintf(
+    "ALTER TABLE %s ADD COLUMN IF NOT EXISTS thumbnail_url VARCHAR(500) NOT NULL DEFAULT ''",
+    blogData.IndexTable))
+  s.Database.DB.Exec(fmt.Sprintf(
+    "ALTER TABLE %s ADD COLUMN IF NOT EXISTS description VARCHAR(500) NOT NULL DEFAULT ''",
+    blogData.IndexTable))
+  s.Database.DB.Exec(fmt.Sprintf(
+    "ALTER TABLE %s ADD COLUMN IF NOT EXISTS content_type VARCHAR(20) NOT NULL DEFAULT '%s'",
+    blogData.IndexTable, ContentTypeMarkdown))
+}
+
+// sqlExecutor is satisfied by both *sql.DB and *sql.Tx, letting tag
+// reconciliation run identically whether called during startup migration
+// or inside a Controller.Post/Put transaction.
+type sqlExecutor interface {
+  ExecContext (ctx context.Context, query string, args ...any) (sql.Result, error)
+  QueryRowContext (ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// upsertTagID normalizes name, inserting it into TagTable if it is not
+// already present, and returns its id either way.
+func upsertTagID (ctx context.Context, dbx sqlExecutor, name string) (int64, error) {
+  insert := fmt.Sprintf("INSERT IGNORE INTO %s (name) VALUES (?)", blogData.TagTable)
+  if _, err := dbx.ExecContext(ctx, insert, name); nil != err {
+    return 0, err
+  }
+
+  var id int64
+  q := fmt.Sprintf("SELECT id FROM %s WHERE name = ?", blogData.TagTable)
+  if err := dbx.QueryRowContext(ctx, q, name).Scan(&id); nil != err {
+    return 0, err
+  }
+  return id, nil
+}
+
+// normalizeTags lowercases and trims each tag, dropping empty and
+// duplicate entries while preserving order.
+func normalizeTags (tags []string) []string {
+  seen := make(map[string]bool, len(tags))
+  var out []string
+  for _, tag := range tags {
+    name := strings.ToLower(strings.TrimSpace(tag))
+    if "" == name || seen[name] {
+      continue
+    }
+    seen[name] = true
+    out = append(out, name)
+  }
+  return out
+}
+
+// normalizeContentType defaults an empty content type to ContentTypeMarkdown
+// and rejects anything outside the known set.
+func normalizeContentType (contentType string) (string, error) {
+  if "" == contentType {
+    return ContentTypeMarkdown, nil
+  }
+  switch contentType {
+  case ContentTypeMarkdown, ContentTypeHTML, ContentTypeText:
+    return contentType, nil
+  default:
+    return "", fmt.Errorf("Invalid content type %q", contentType)
+  }
+}
+
+// deriveDescription returns description unchanged if the caller supplied
+// one, otherwise falls back to the first DescriptionMaxLength runes of body.
+func deriveDescription (description, body string) string {
+  if "" != description {
+    return description
+  }
+  runes := []rune(strings.TrimSpace(body))
+  if len(runes) > DescriptionMaxLength {
+    runes = runes[:DescriptionMaxLength]
+  }
+  return string(runes)
+}
+
+// htmlSanitizer strips everything outside a user-generated-content-safe
+// tag/attribute set from rendered markdown before it reaches BodyHTML.
+var htmlSanitizer *bluemonday.Policy = bluemonday.UGCPolicy()
+
+// renderMarkdown converts body from Markdown to sanitized HTML.
+func renderMarkdown (body string) (string, error) {
+  var buf bytes.Buffer
+  if err := goldmark.Convert([]byte(body), &buf); nil != err {
+    return "", err
+  }
+  return htmlSanitizer.Sanitize(buf.String()), nil
+}
+
+// bodyHTML renders body into BodyHTML when contentType is markdown; every
+// other content type is returned to clients as-is, with no server-side
+// rendering.
+func bodyHTML (contentType, body string) (string, error) {
+  if ContentTypeMarkdown != contentType {
+    return "", nil
+  }
+  return renderMarkdown(body)
+}
+
+// tagsForPage returns the tags attached to pageID, ordered by name.
+func tagsForPage (s route.Service, d blogDataType, pageID int64) ([]string, error) {
+  q := fmt.Sprintf("SELECT t.name FROM %s AS pt INNER JOIN %s AS t " +
+                   "ON pt.tag_id = t.id " +
+                   "WHERE pt.page_id = ? ORDER BY t.name", d.TagJoinTable, d.TagTable)
+  rows, err := s.Database.DB.Query(q, pageID)
+  if nil != err {
+    return nil, err
+  }
+  defer rows.Close()
+
+  var tags []string
+  for rows.Next() {
+    var name string
+    if err = rows.Scan(&name); nil != err {
+      return nil, err
+    }
+    tags = append(tags, name)
+  }
+  return tags, nil
+}
+
+// placeholders returns a comma-separated list of n "?" placeholders.
+func placeholders (n int) string {
+  marks := make([]string, n)
+  for i := range marks {
+    marks[i] = "?"
+  }
+  return strings.Join(marks, ",")
+}
+
 
 /*\
  *******************************************************************************
@@ -141,6 +422,42 @@ func (c *ListController) Timeout () time.Duration {
 }
 
 
+// TagsController
+
+func (c *TagsController) Route () string {
+  return "/" + c.Name
+}
+
+func (c *TagsController) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *TagsController) Timeout () time.Duration {
+  return c.Limit
+}
+
+
+// RevisionsController
+
+func (c *RevisionsController) Route () string {
+  return "/" + c.Name + "/revisions"
+}
+
+func (c *RevisionsController) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *RevisionsController) Timeout () time.Duration {
+  return c.Limit
+}
+
+
 /*\
  *******************************************************************************
  *                             Interface: Restful                              *
@@ -150,21 +467,73 @@ func (c *ListController) Timeout () time.Duration {
 
 // Controller
 
+// slugPunctuation matches runs of characters that are not lowercase
+// letters or digits, so they can be collapsed to a single separator.
+var slugPunctuation *regexp.Regexp = regexp.MustCompile("[^a-z0-9]+")
+
+// slugify derives a URL-safe slug from a title: lowercase, non-alphanumeric
+// runs collapsed to "-", leading/trailing "-" trimmed, capped at
+// SlugMaxLength.
+func slugify (title string) string {
+  slug := slugPunctuation.ReplaceAllString(strings.ToLower(title), "-")
+  slug = strings.Trim(slug, "-")
+
+  if len(slug) > SlugMaxLength {
+    slug = strings.Trim(slug[:SlugMaxLength], "-")
+  }
+
+  if "" == slug {
+    slug = "post"
+  }
+
+  return slug
+}
+
+// uniqueSlug appends -2, -3, ... to base until a slug not already present
+// in IndexTable is found, ignoring excludeID so re-saving a post under its
+// own unchanged slug doesn't bump it to a new one. Pass an excludeID that
+// matches no row (e.g. "0") when there is no existing post to exclude.
+func (c *Controller) uniqueSlug (base string, excludeID string) (string, error) {
+  q := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE slug = ? AND id != ?", c.Data.IndexTable)
+
+  slug := base
+  for n := 2; ; n++ {
+    var count int
+    if err := c.Service.Database.DB.QueryRow(q, slug, excludeID).Scan(&count); nil != err {
+      return "", err
+    }
+    if 0 == count {
+      return slug, nil
+    }
+    slug = fmt.Sprintf("%s-%d", base, n)
+  }
+}
+
 type BlogResponse struct {
-  ID       string `json:"id"`
-  Title    string `json:"title"`
-  Subtitle string `json:"subtitle"`
-  Tag      string `json:"tag"`
-  Body     string `json:"body"`
-  Created  string `json:"created"`
-  Updated  string `json:"updated"`
+  ID             string   `json:"id"`
+  Slug           string   `json:"slug"`
+  Title          string   `json:"title"`
+  Subtitle       string   `json:"subtitle"`
+  Tags           []string `json:"tags"`
+  AuthorName     string   `json:"author_name"`
+  AuthorImageURL string   `json:"author_image_url"`
+  ThumbnailURL   string   `json:"thumbnail_url"`
+  Description    string   `json:"description"`
+  ContentType    string   `json:"content_type"`
+  Body           string   `json:"body"`
+  BodyHTML       string   `json:"body_html"`
+  Created        string   `json:"created"`
+  Updated        string   `json:"updated"`
 }
 
 func (c *Controller) Get (x context.Context, rq *http.Request, re *route.Result) error {
   var (
-    blog    BlogResponse = BlogResponse{}
-    blog_id int          = -1
-    err     error        = nil
+    blog   BlogResponse = BlogResponse{}
+    slug   string       = rq.URL.Query().Get("slug")
+    revStr string       = rq.URL.Query().Get("revision")
+    pageID int64
+    args   []any
+    q      string
   )
 
   fail := func(err error, status int) error {
@@ -172,18 +541,32 @@ func (c *Controller) Get (x context.Context, rq *http.Request, re *route.Result)
     return err
   }
 
-  q := fmt.Sprintf("SELECT a.id, a.title, a.subtitle, a.tag, b.body, b.created, b.updated " + 
-                   "FROM %s AS a INNER JOIN %s AS b " +
-		   "ON a.content_id = b.id " + 
-		   "WHERE a.id = ?", c.Data.IndexTable, c.Data.ContentTable)
-
-  // Validate ID
-  if blog_id, err = strconv.Atoi(rq.URL.Query().Get("id")); nil != err {
-    return fail(fmt.Errorf("Invalid query parameter"), http.StatusBadRequest)
+  if "" != slug {
+    q = fmt.Sprintf("SELECT a.id, a.slug, a.title, a.subtitle, a.author_name, " +
+                    "a.author_image_url, a.thumbnail_url, a.description, a.content_type, " +
+                    "b.body, b.created, b.updated " +
+                    "FROM %s AS a INNER JOIN %s AS b " +
+		    "ON a.content_id = b.id " +
+		    "WHERE a.slug = ? AND a.deleted_at IS NULL AND b.deleted_at IS NULL",
+		    c.Data.IndexTable, c.Data.ContentTable)
+    args = []any{slug}
+  } else {
+    blog_id, err := strconv.Atoi(rq.URL.Query().Get("id"))
+    if nil != err {
+      return fail(fmt.Errorf("Invalid query parameter"), http.StatusBadRequest)
+    }
+    q = fmt.Sprintf("SELECT a.id, a.slug, a.title, a.subtitle, a.author_name, " +
+                    "a.author_image_url, a.thumbnail_url, a.description, a.content_type, " +
+                    "b.body, b.created, b.updated " +
+                    "FROM %s AS a INNER JOIN %s AS b " +
+		    "ON a.content_id = b.id " +
+		    "WHERE a.id = ? AND a.deleted_at IS NULL AND b.deleted_at IS NULL",
+		    c.Data.IndexTable, c.Data.ContentTable)
+    args = []any{blog_id}
   }
 
   // Extract row
-  rows, err := c.Service.Database.DB.Query(q, blog_id)
+  rows, err := c.Service.Database.DB.Query(q, args...)
   if nil != err {
     return fail(err, http.StatusInternalServerError)
   }
@@ -191,12 +574,46 @@ func (c *Controller) Get (x context.Context, rq *http.Request, re *route.Result)
 
   // Verify entry exists
   if !rows.Next() {
-    return fail(fmt.Errorf("Blog %s not found", blog_id), http.StatusNotFound)
+    return fail(fmt.Errorf("Blog not found"), http.StatusNotFound)
   }
 
   // Marshal rows
-  if err = rows.Scan(&blog.ID, &blog.Title, &blog.Subtitle, &blog.Tag,
-    &blog.Body, &blog.Created, &blog.Updated); nil != err {
+  if err = rows.Scan(&pageID, &blog.Slug, &blog.Title, &blog.Subtitle,
+    &blog.AuthorName, &blog.AuthorImageURL, &blog.ThumbnailURL, &blog.Description,
+    &blog.ContentType, &blog.Body, &blog.Created, &blog.Updated); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+  blog.ID = strconv.FormatInt(pageID, 10)
+  rows.Close()
+
+  // An explicit ?revision=N pins the response to a historical version
+  // archived in RevisionTable instead of the current one
+  if "" != revStr {
+    revision, convErr := strconv.Atoi(revStr)
+    if nil != convErr {
+      return fail(fmt.Errorf("Invalid revision parameter"), http.StatusBadRequest)
+    }
+
+    var editedAt time.Time
+    revQuery := fmt.Sprintf("SELECT title, subtitle, body, edited_at FROM %s " +
+                            "WHERE page_id = ? AND revision_no = ?", c.Data.RevisionTable)
+    if err = c.Service.Database.DB.QueryRow(revQuery, pageID, revision).Scan(
+      &blog.Title, &blog.Subtitle, &blog.Body, &editedAt); nil != err {
+      if sql.ErrNoRows == err {
+        return fail(fmt.Errorf("Revision not found"), http.StatusNotFound)
+      }
+      return fail(err, http.StatusInternalServerError)
+    }
+    blog.Updated = editedAt.Format(c.Data.TimeFormat)
+  }
+
+  // Attach tags
+  if blog.Tags, err = tagsForPage(c.Service, c.Data, pageID); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Render markdown bodies server-side so clients don't need their own renderer
+  if blog.BodyHTML, err = bodyHTML(blog.ContentType, blog.Body); nil != err {
     return fail(err, http.StatusInternalServerError)
   }
 
@@ -205,51 +622,73 @@ func (c *Controller) Get (x context.Context, rq *http.Request, re *route.Result)
 }
 
 type BlogPost struct {
-  Title    string `json:"title"`
-  Subtitle string `json:"subtitle"`
-  Tag      string `json:"tag"`
-  Body     string `json:"body"`
+  Title          string   `json:"title" validate:"required,min=1,max=200"`
+  Subtitle       string   `json:"subtitle" validate:"max=200"`
+  Tags           []string `json:"tags"`
+  AuthorName     string   `json:"author_name" validate:"max=200"`
+  AuthorImageURL string   `json:"author_image_url" validate:"url"`
+  ThumbnailURL   string   `json:"thumbnail_url" validate:"url"`
+  Description    string   `json:"description" validate:"max=500"`
+  ContentType    string   `json:"content_type"`
+  Body           string   `json:"body" validate:"max=100000"`
 }
 
 type BlogPostResponse struct {
-  ID       string `json:"id"`
-  Title    string `json:"title"`
-  Subtitle string `json:"subtitle"`
-  Tag      string `json:"tag"`
-  Body     string `json:"body"`
-  Created  string `json:"created"`
-  Updated  string `json:"updated"`
+  ID             string   `json:"id"`
+  Slug           string   `json:"slug"`
+  Title          string   `json:"title"`
+  Subtitle       string   `json:"subtitle"`
+  Tags           []string `json:"tags"`
+  AuthorName     string   `json:"author_name"`
+  AuthorImageURL string   `json:"author_image_url"`
+  ThumbnailURL   string   `json:"thumbnail_url"`
+  Description    string   `json:"description"`
+  ContentType    string   `json:"content_type"`
+  Body           string   `json:"body"`
+  BodyHTML       string   `json:"body_html"`
+  Created        string   `json:"created"`
+  Updated        string   `json:"updated"`
 }
 
 func (c *Controller) Post (x context.Context, rq *http.Request, re *route.Result) error {
   var (
-    body      []byte                  = []byte{}
     err       error                   = nil
     ip        string                  = x.Value(user.UserIPKey).(string)
     post      auth.AuthData[BlogPost] = auth.AuthData[BlogPost]{}
     timeStamp time.Time               = time.Now().UTC()
+    slug      string
+    tags      []string
   )
 
   fail := func (err error, status int) error {
     re.Status = status
     return err
   }
-  
-  // Read request body
-  if body, err = ioutil.ReadAll(rq.Body); nil != err {
-    return fail(err, http.StatusInternalServerError)
-  }
 
-  // Unmarshal to type
-  if err = json.Unmarshal(body, &post); nil != err {
-    return fail(err, http.StatusBadRequest)
+  // Read, unmarshal, and validate the request body
+  if err = re.Bind(rq, &post); nil != err {
+    return err
   }
 
   // Check if authorized
   if err = c.Service.Auth.Authorized(ip, post.Username, post.Secret); nil != err {
     return fail(err, http.StatusUnauthorized)
   }
-    
+
+  // Derive a unique slug from the title; there is no existing post yet, so
+  // no ID needs to be excluded from the collision check
+  if slug, err = c.uniqueSlug(slugify(post.Data.Title), "0"); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  contentType, err := normalizeContentType(post.Data.ContentType)
+  if nil != err {
+    return fail(err, http.StatusBadRequest)
+  }
+  description := deriveDescription(post.Data.Description, post.Data.Body)
+
+  tags = normalizeTags(post.Data.Tags)
+
   // Define insert content
   insertBody := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
     q := fmt.Sprintf("INSERT INTO %s (created,updated,body) VALUES (?,?,?)",
@@ -264,14 +703,37 @@ func (c *Controller) Post (x context.Context, rq *http.Request, re *route.Result
     if nil != err {
       return nil, err
     }
-    q := fmt.Sprintf("INSERT INTO %s (title,subtitle,tag,content_id) " +
-      "VALUES (?,?,?,?)", c.Data.IndexTable)
-    return t.ExecContext(c.Service.Database.Context, q, post.Data.Title, 
-      post.Data.Subtitle, post.Data.Tag, id)
+    q := fmt.Sprintf("INSERT INTO %s (title,subtitle,slug,author_name,author_image_url," +
+      "thumbnail_url,description,content_type,content_id) VALUES (?,?,?,?,?,?,?,?,?)",
+      c.Data.IndexTable)
+    return t.ExecContext(c.Service.Database.Context, q, post.Data.Title,
+      post.Data.Subtitle, slug, post.Data.AuthorName, post.Data.AuthorImageURL,
+      post.Data.ThumbnailURL, description, contentType, id)
+  }
+
+  // Reconcile tags against the new page inside the same transaction, so a
+  // failed tag write rolls back the post; preserves insertRecord's result
+  // so the page ID is still recoverable afterwards.
+  insertTags := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+    pageID, err := lastResult.LastInsertId()
+    if nil != err {
+      return nil, err
+    }
+    for _, name := range tags {
+      tagID, err := upsertTagID(c.Service.Database.Context, t, name)
+      if nil != err {
+        return nil, err
+      }
+      q := fmt.Sprintf("INSERT IGNORE INTO %s (page_id,tag_id) VALUES (?,?)", c.Data.TagJoinTable)
+      if _, err = t.ExecContext(c.Service.Database.Context, q, pageID, tagID); nil != err {
+        return nil, err
+      }
+    }
+    return lastResult, nil
   }
 
   // Execute sequenced insert operations; get back result
-  r, err := c.Service.Database.Transaction(insertBody, insertRecord)
+  r, err := c.Service.Database.Transaction(insertBody, insertRecord, insertTags)
   if nil != err {
     return fail(err, http.StatusInternalServerError)
   }
@@ -282,43 +744,74 @@ func (c *Controller) Post (x context.Context, rq *http.Request, re *route.Result
     return fail(err, http.StatusInternalServerError)
   }
 
+  // Render markdown bodies server-side so clients don't need their own renderer
+  rendered, err := bodyHTML(contentType, post.Data.Body)
+  if nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
   // Write to buffer and return any encoding error
-  return re.Marshal(route.ContentTypeJSON, 
+  return re.Marshal(route.ContentTypeJSON,
     &BlogPostResponse {
-      ID:       strconv.FormatInt(id, 10),
-      Title:    post.Data.Title,
-      Subtitle: post.Data.Subtitle,
-      Tag:      post.Data.Tag,
-      Body:     post.Data.Body,
-      Created:  timeStamp.Format(c.Data.TimeFormat),
-      Updated:  timeStamp.Format(c.Data.TimeFormat),
+      ID:             strconv.FormatInt(id, 10),
+      Slug:           slug,
+      Title:          post.Data.Title,
+      Subtitle:       post.Data.Subtitle,
+      Tags:           tags,
+      AuthorName:     post.Data.AuthorName,
+      AuthorImageURL: post.Data.AuthorImageURL,
+      ThumbnailURL:   post.Data.ThumbnailURL,
+      Description:    description,
+      ContentType:    contentType,
+      Body:           post.Data.Body,
+      BodyHTML:       rendered,
+      Created:        timeStamp.Format(c.Data.TimeFormat),
+      Updated:        timeStamp.Format(c.Data.TimeFormat),
     })
 }
 
 type BlogPut struct {
-  ID       string `json:"id"`
-  Title    string `json:"title"`
-  Subtitle string `json:"subtitle"`
-  Tag      string `json:"tag"`
-  Body     string `json:"body"`
+  ID             string   `json:"id" validate:"required,numeric"`
+  Slug           string   `json:"slug"`
+  Title          string   `json:"title" validate:"max=200"`
+  Subtitle       string   `json:"subtitle" validate:"max=200"`
+  Tags           []string `json:"tags"`
+  AuthorName     string   `json:"author_name" validate:"max=200"`
+  AuthorImageURL string   `json:"author_image_url" validate:"url"`
+  ThumbnailURL   string   `json:"thumbnail_url" validate:"url"`
+  Description    string   `json:"description" validate:"max=500"`
+  ContentType    string   `json:"content_type"`
+  Body           string   `json:"body" validate:"max=100000"`
 }
 
 type BlogPutResponse struct {
-  ID       string `json:"id"`
-  Title    string `json:"title"`
-  Subtitle string `json:"subtitle"`
-  Tag      string `json:"tag"`
-  Updated  string `json:"updated"`
-  Body     string `json:"body"`
+  ID             string   `json:"id"`
+  Slug           string   `json:"slug"`
+  Title          string   `json:"title"`
+  Subtitle       string   `json:"subtitle"`
+  Tags           []string `json:"tags"`
+  AuthorName     string   `json:"author_name"`
+  AuthorImageURL string   `json:"author_image_url"`
+  ThumbnailURL   string   `json:"thumbnail_url"`
+  Description    string   `json:"description"`
+  ContentType    string   `json:"content_type"`
+  Updated        string   `json:"updated"`
+  Body           string   `json:"body"`
+  BodyHTML       string   `json:"body_html"`
+}
+
+type BlogRestoreResponse struct {
+  ID string `json:"id"`
 }
 
 func (c *Controller) Put (x context.Context, rq *http.Request, re *route.Result) error {
   var (
-    body      []byte                 = []byte{}
     err       error                  = nil
     ip        string                 = x.Value(user.UserIPKey).(string)
     post      auth.AuthData[BlogPut] = auth.AuthData[BlogPut]{}
     timeStamp time.Time              = time.Now().UTC()
+    slug      string
+    tags      []string
   )
 
   fail := func (err error, status int) error {
@@ -326,32 +819,138 @@ func (c *Controller) Put (x context.Context, rq *http.Request, re *route.Result)
     return err
   }
 
-  // Define update record
-  updateRecord := func (lastResult sql.Result, conn *sql.Conn) (sql.Result, error) {
-    q := fmt.Sprintf("UPDATE %s AS a INNER JOIN %s AS b ON a.content_id = b.id " +
-                     "SET a.title = ?, a.subtitle = ?, b.updated = ?, b.body = ? " +
-		     "WHERE a.id = ?", c.Data.IndexTable, c.Data.ContentTable)
-    return conn.ExecContext(c.Service.Database.Context, q, post.Data.Title, 
-      post.Data.Subtitle, timeStamp, post.Data.Body, post.Data.ID)
+  // Read, unmarshal, and validate the request body
+  if err = re.Bind(rq, &post); nil != err {
+    return err
   }
 
-  // Read request body
-  if body, err = ioutil.ReadAll(rq.Body); nil != err {
-    return fail(err, http.StatusInternalServerError)
+  // Check if authorized
+  if err = c.Service.Auth.Authorized(ip, post.Username, post.Secret); nil != err {
+    return fail(err, http.StatusUnauthorized)
+  }
+
+  // ?restore=1 only clears deleted_at; it does not touch content or tags.
+  // There is no separate admin role in this tree, so this reuses the same
+  // authenticated-write gate as every other mutation.
+  if "1" == rq.URL.Query().Get("restore") {
+    restoreRecord := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+      q := fmt.Sprintf("UPDATE %s AS a INNER JOIN %s AS b ON a.content_id = b.id " +
+                       "SET a.deleted_at = NULL, b.deleted_at = NULL " +
+		       "WHERE a.id = ?", c.Data.IndexTable, c.Data.ContentTable)
+      return t.ExecContext(c.Service.Database.Context, q, post.Data.ID)
+    }
+
+    r, err := c.Service.Database.Transaction(restoreRecord)
+    if nil != err {
+      return fail(err, http.StatusInternalServerError)
+    }
+
+    n, err := r.RowsAffected()
+    if nil != err {
+      return fail(err, http.StatusInternalServerError)
+    } else if 0 == n {
+      return fail(fmt.Errorf("Blog not found"), http.StatusNotFound)
+    }
+
+    return re.Marshal(route.ContentTypeJSON, &BlogRestoreResponse{ ID: post.Data.ID })
+  }
+
+  // Only regenerate the slug when the caller explicitly supplies one, so
+  // existing permalinks stay stable across ordinary edits
+  if "" != post.Data.Slug {
+    if slug, err = c.uniqueSlug(slugify(post.Data.Slug), post.Data.ID); nil != err {
+      return fail(err, http.StatusInternalServerError)
+    }
   }
 
-  // Unmarshal to type
-  if err = json.Unmarshal(body, &post); nil != err {
+  tags = normalizeTags(post.Data.Tags)
+
+  contentType, err := normalizeContentType(post.Data.ContentType)
+  if nil != err {
     return fail(err, http.StatusBadRequest)
   }
+  description := deriveDescription(post.Data.Description, post.Data.Body)
 
-  // Check if authorized
-  if err = c.Service.Auth.Authorized(ip, post.Username, post.Secret); nil != err {
-    return fail(err, http.StatusUnauthorized)
+  // Resolve the content this edit is about to supersede, so it can be
+  // archived before being overwritten
+  var (
+    priorTitle, priorSubtitle, priorBody string
+    revision                             int
+  )
+  priorQuery := fmt.Sprintf("SELECT a.title, a.subtitle, b.body, " +
+                            "COALESCE((SELECT MAX(revision_no) FROM %s WHERE page_id = a.id), 0) " +
+                            "FROM %s AS a INNER JOIN %s AS b ON a.content_id = b.id " +
+                            "WHERE a.id = ? AND a.deleted_at IS NULL AND b.deleted_at IS NULL",
+                            c.Data.RevisionTable, c.Data.IndexTable, c.Data.ContentTable)
+  if err = c.Service.Database.DB.QueryRow(priorQuery, post.Data.ID).Scan(
+    &priorTitle, &priorSubtitle, &priorBody, &revision); nil != err {
+    if sql.ErrNoRows == err {
+      return fail(fmt.Errorf("Blog not found"), http.StatusNotFound)
+    }
+    return fail(err, http.StatusInternalServerError)
   }
 
-  // Execute sequenced connection operations; get back result
-  r, err := c.Service.Database.Connection(updateRecord)
+  // Define archive of the content being superseded
+  archiveRevision := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+    q := fmt.Sprintf("INSERT INTO %s (page_id,revision_no,title,subtitle,body,edited_at,edited_by) " +
+                     "VALUES (?,?,?,?,?,?,?)", c.Data.RevisionTable)
+    return t.ExecContext(c.Service.Database.Context, q, post.Data.ID, revision + 1,
+      priorTitle, priorSubtitle, priorBody, timeStamp, post.Username)
+  }
+
+  // Define update record
+  updateRecord := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+    if "" != slug {
+      q := fmt.Sprintf("UPDATE %s AS a INNER JOIN %s AS b ON a.content_id = b.id " +
+                       "SET a.title = ?, a.subtitle = ?, a.slug = ?, a.author_name = ?, " +
+                       "a.author_image_url = ?, a.thumbnail_url = ?, a.description = ?, " +
+                       "a.content_type = ?, b.updated = ?, b.body = ? " +
+		       "WHERE a.id = ? AND a.deleted_at IS NULL",
+		       c.Data.IndexTable, c.Data.ContentTable)
+      return t.ExecContext(c.Service.Database.Context, q, post.Data.Title,
+        post.Data.Subtitle, slug, post.Data.AuthorName, post.Data.AuthorImageURL,
+        post.Data.ThumbnailURL, description, contentType, timeStamp, post.Data.Body,
+        post.Data.ID)
+    }
+    q := fmt.Sprintf("UPDATE %s AS a INNER JOIN %s AS b ON a.content_id = b.id " +
+                     "SET a.title = ?, a.subtitle = ?, a.author_name = ?, " +
+                     "a.author_image_url = ?, a.thumbnail_url = ?, a.description = ?, " +
+                     "a.content_type = ?, b.updated = ?, b.body = ? " +
+		     "WHERE a.id = ? AND a.deleted_at IS NULL",
+		     c.Data.IndexTable, c.Data.ContentTable)
+    return t.ExecContext(c.Service.Database.Context, q, post.Data.Title,
+      post.Data.Subtitle, post.Data.AuthorName, post.Data.AuthorImageURL,
+      post.Data.ThumbnailURL, description, contentType, timeStamp, post.Data.Body,
+      post.Data.ID)
+  }
+
+  // Replace the page's tag set entirely so it always matches the request,
+  // preserving updateRecord's result so the rows-affected check below
+  // still reflects the post update rather than the tag housekeeping
+  deleteTags := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+    q := fmt.Sprintf("DELETE FROM %s WHERE page_id = ?", c.Data.TagJoinTable)
+    if _, err := t.ExecContext(c.Service.Database.Context, q, post.Data.ID); nil != err {
+      return nil, err
+    }
+    return lastResult, nil
+  }
+
+  insertTags := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+    for _, name := range tags {
+      tagID, err := upsertTagID(c.Service.Database.Context, t, name)
+      if nil != err {
+        return nil, err
+      }
+      q := fmt.Sprintf("INSERT IGNORE INTO %s (page_id,tag_id) VALUES (?,?)", c.Data.TagJoinTable)
+      if _, err = t.ExecContext(c.Service.Database.Context, q, post.Data.ID, tagID); nil != err {
+        return nil, err
+      }
+    }
+    return lastResult, nil
+  }
+
+  // Execute sequenced transaction operations; get back result
+  r, err := c.Service.Database.Transaction(archiveRevision, updateRecord, deleteTags, insertTags)
   if nil != err {
     return fail(err, http.StatusInternalServerError)
   }
@@ -361,29 +960,41 @@ func (c *Controller) Put (x context.Context, rq *http.Request, re *route.Result)
   if nil != err {
     return fail(err, http.StatusInternalServerError)
   } else if 0 == n {
-    return fail(fmt.Errorf("Unexpected database result (no rows modified)"), 
+    return fail(fmt.Errorf("Unexpected database result (no rows modified)"),
       http.StatusInternalServerError)
   }
 
+  // Render markdown bodies server-side so clients don't need their own renderer
+  rendered, err := bodyHTML(contentType, post.Data.Body)
+  if nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
   // No difference is needed here in the return type
   return re.Marshal(route.ContentTypeJSON,
     &BlogPutResponse {
-      ID:       post.Data.ID,
-      Title:    post.Data.Title,
-      Subtitle: post.Data.Subtitle,
-      Tag:      post.Data.Tag,
-      Updated:  timeStamp.Format(c.Data.TimeFormat),
-      Body:     post.Data.Body,
+      ID:             post.Data.ID,
+      Slug:           slug,
+      Title:          post.Data.Title,
+      Subtitle:       post.Data.Subtitle,
+      Tags:           tags,
+      AuthorName:     post.Data.AuthorName,
+      AuthorImageURL: post.Data.AuthorImageURL,
+      ThumbnailURL:   post.Data.ThumbnailURL,
+      Description:    description,
+      ContentType:    contentType,
+      Updated:        timeStamp.Format(c.Data.TimeFormat),
+      Body:           post.Data.Body,
+      BodyHTML:       rendered,
     })
 }
 
 type BlogDelete struct {
-  ID string `json:"id"`
+  ID string `json:"id" validate:"required,numeric"`
 }
 
 func (c *Controller) Delete (x context.Context, rq *http.Request, re *route.Result) error {
   var (
-    body  []byte                    = []byte{}
     err   error                     = nil
     ip    string                    = x.Value(user.UserIPKey).(string)
     post  auth.AuthData[BlogDelete] = auth.AuthData[BlogDelete]{}
@@ -394,22 +1005,19 @@ func (c *Controller) Delete (x context.Context, rq *http.Request, re *route.Resu
     return err
   }
 
-  // Define delete record
-  deleteRecord := func (lastResult sql.Result, conn *sql.Conn) (sql.Result, error) {
-    q := fmt.Sprintf("DELETE a, b FROM %s AS a INNER JOIN %s AS b " +
-                     "ON a.content_id = b.id " +
-                     "WHERE a.id = ?", c.Data.IndexTable, c.Data.ContentTable)
-    return conn.ExecContext(c.Service.Database.Context, q, post.Data.ID)
-  }
-
-  // Read request body
-  if body, err = ioutil.ReadAll(rq.Body); nil != err {
-    return fail(err, http.StatusInternalServerError)
+  // Define soft-delete of the page and its content; revisions are left intact
+  softDelete := func (lastResult sql.Result, conn *sql.Conn) (sql.Result, error) {
+    now := time.Now().UTC()
+    q := fmt.Sprintf("UPDATE %s AS a INNER JOIN %s AS b ON a.content_id = b.id " +
+                     "SET a.deleted_at = ?, b.deleted_at = ? " +
+                     "WHERE a.id = ? AND a.deleted_at IS NULL",
+		     c.Data.IndexTable, c.Data.ContentTable)
+    return conn.ExecContext(c.Service.Database.Context, q, now, now, post.Data.ID)
   }
 
-  // Unmarshal to type
-  if err = json.Unmarshal(body, &post); nil != err {
-    return fail(err, http.StatusBadRequest)
+  // Read, unmarshal, and validate the request body
+  if err = re.Bind(rq, &post); nil != err {
+    return err
   }
 
   // Check if authorized
@@ -419,18 +1027,17 @@ func (c *Controller) Delete (x context.Context, rq *http.Request, re *route.Resu
 
 
   // Execute sequenced connection operations; get back result
-  r, err := c.Service.Database.Connection(deleteRecord)
+  r, err := c.Service.Database.Connection(softDelete)
   if nil != err {
-    fail(err, http.StatusInternalServerError)
+    return fail(err, http.StatusInternalServerError)
   }
 
   // Verify the right number of rows were affected
   n, err := r.RowsAffected()
   if nil != err {
-    fail(err, http.StatusInternalServerError)
-  } else if 2 != n {
-    fail(fmt.Errorf("Unexpected database result (expected %d rows affected, got %d)",
-      2, n), http.StatusInternalServerError)
+    return fail(err, http.StatusInternalServerError)
+  } else if 0 == n {
+    return fail(fmt.Errorf("Blog not found"), http.StatusNotFound)
   }
 
   return nil
@@ -440,48 +1047,202 @@ func (c *Controller) Delete (x context.Context, rq *http.Request, re *route.Resu
 // ListController
 
 type BlogHeader struct {
-  ID       string `json:"id"`
-  Title    string `json:"title"`
-  Subtitle string `json:"subtitle"`
-  Tag      string `json:"tag"`
-  Created  string `json:"created"`
-  Updated  string `json:"updated"`
+  ID           string   `json:"id"`
+  Slug         string   `json:"slug"`
+  Title        string   `json:"title"`
+  Subtitle     string   `json:"subtitle"`
+  Tags         []string `json:"tags"`
+  Description  string   `json:"description"`
+  ThumbnailURL string   `json:"thumbnail_url"`
+  Created      string   `json:"created"`
+  Updated      string   `json:"updated"`
+}
+
+// sortColumns whitelists the SQL column behind each accepted ?sort= value,
+// so the query string is never interpolated directly into ORDER BY.
+var sortColumns map[string]string = map[string]string {
+  "created": "b.created",
+  "updated": "b.updated",
+  "title":   "a.title",
+}
+
+type BlogListResponse struct {
+  Items  []BlogHeader `json:"items"`
+  Total  int          `json:"total"`
+  Limit  int          `json:"limit"`
+  Offset int          `json:"offset"`
 }
 
 func (c *ListController) Get (x context.Context, rq *http.Request, re *route.Result) error {
   var (
     head BlogHeader
     list []BlogHeader
+    tags []string = normalizeTags(rq.URL.Query()["tag"])
+    where []string = []string{"a.deleted_at IS NULL", "b.deleted_at IS NULL"}
+    args  []any
   )
-  q := fmt.Sprintf("SELECT a.id, a.title, a.subtitle, a.tag, b.created, b.updated " +
-                   "FROM %s AS a INNER JOIN %s AS b " + 
-                   "ON a.content_id = b.id " +
-                   "ORDER BY b.created", c.Data.IndexTable, c.Data.ContentTable)
+
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  // limit: default 20, capped at 100
+  limit := 20
+  if s := rq.URL.Query().Get("limit"); "" != s {
+    n, err := strconv.Atoi(s)
+    if nil != err || n < 1 || n > 100 {
+      return fail(fmt.Errorf("Invalid limit parameter"), http.StatusBadRequest)
+    }
+    limit = n
+  }
+
+  // offset: default 0
+  offset := 0
+  if s := rq.URL.Query().Get("offset"); "" != s {
+    n, err := strconv.Atoi(s)
+    if nil != err || n < 0 {
+      return fail(fmt.Errorf("Invalid offset parameter"), http.StatusBadRequest)
+    }
+    offset = n
+  }
+
+  // sort: whitelisted column, default created
+  sortKey := rq.URL.Query().Get("sort")
+  if "" == sortKey {
+    sortKey = "created"
+  }
+  sortColumn, ok := sortColumns[sortKey]
+  if !ok {
+    return fail(fmt.Errorf("Invalid sort parameter"), http.StatusBadRequest)
+  }
+
+  // order: asc or desc, default desc
+  order := strings.ToLower(rq.URL.Query().Get("order"))
+  if "" == order {
+    order = "desc"
+  }
+  if "asc" != order && "desc" != order {
+    return fail(fmt.Errorf("Invalid order parameter"), http.StatusBadRequest)
+  }
+
+  // Require every requested tag to be present on the page (AND semantics)
+  if 0 != len(tags) {
+    where = append(where, fmt.Sprintf("a.id IN (" +
+                   "SELECT pt.page_id FROM %s AS pt INNER JOIN %s AS t " +
+                   "ON pt.tag_id = t.id " +
+                   "WHERE t.name IN (%s) " +
+                   "GROUP BY pt.page_id HAVING COUNT(DISTINCT t.name) = ?)",
+                   c.Data.TagJoinTable, c.Data.TagTable, placeholders(len(tags))))
+    for _, tag := range tags {
+      args = append(args, tag)
+    }
+    args = append(args, len(tags))
+  }
+
+  // q: case-insensitive substring match against title, subtitle, or tag name
+  if q := rq.URL.Query().Get("q"); "" != q {
+    where = append(where, fmt.Sprintf("(a.title LIKE ? OR a.subtitle LIKE ? OR EXISTS (" +
+                   "SELECT 1 FROM %s AS pt INNER JOIN %s AS t ON pt.tag_id = t.id " +
+                   "WHERE pt.page_id = a.id AND t.name LIKE ?))",
+                   c.Data.TagJoinTable, c.Data.TagTable))
+    like := "%" + q + "%"
+    args = append(args, like, like, like)
+  }
+
+  whereClause := ""
+  if 0 != len(where) {
+    whereClause = "WHERE " + strings.Join(where, " AND ")
+  }
+
+  // Total count, ignoring limit/offset
+  countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s AS a INNER JOIN %s AS b " +
+                            "ON a.content_id = b.id %s",
+                            c.Data.IndexTable, c.Data.ContentTable, whereClause)
+
+  var total int
+  if err := c.Service.Database.DB.QueryRow(countQuery, args...).Scan(&total); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  listQuery := fmt.Sprintf("SELECT a.id, a.slug, a.title, a.subtitle, a.description, " +
+                           "a.thumbnail_url, b.created, b.updated " +
+                           "FROM %s AS a INNER JOIN %s AS b ON a.content_id = b.id %s " +
+                           "ORDER BY %s %s LIMIT ? OFFSET ?",
+                           c.Data.IndexTable, c.Data.ContentTable, whereClause,
+                           sortColumn, strings.ToUpper(order))
+
+  listArgs := append(append([]any{}, args...), limit, offset)
 
   // Extract rows
-  rows, err := c.Service.Database.DB.Query(q)
+  rows, err := c.Service.Database.DB.Query(listQuery, listArgs...)
   if nil != err {
-    return err
+    return fail(err, http.StatusInternalServerError)
   }
   defer rows.Close()
 
   // Marshal rows
   for rows.Next() {
-    if err = rows.Scan(&head.ID, &head.Title, &head.Subtitle, &head.Tag, &head.Created,
-      &head.Updated); nil != err {
+    var pageID int64
+    if err = rows.Scan(&pageID, &head.Slug, &head.Title, &head.Subtitle,
+      &head.Description, &head.ThumbnailURL, &head.Created, &head.Updated); nil != err {
         break
-      } else {
-        list = append(list, head)
       }
+    head.ID = strconv.FormatInt(pageID, 10)
+    if head.Tags, err = tagsForPage(c.Service, c.Data, pageID); nil != err {
+      break
+    }
+    list = append(list, head)
   }
 
   // Check error
   if nil != err {
-    return err
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Emit pagination headers so clients can render pagers without parsing the body
+  re.Headers.Set("X-Total-Count", strconv.Itoa(total))
+  if links := paginationLinks(rq, limit, offset, total); "" != links {
+    re.Headers.Set("Link", links)
   }
 
   // Write to buffer and return any encoding error
-  return re.Marshal(route.ContentTypeJSON, &list)
+  return re.Marshal(route.ContentTypeJSON, &BlogListResponse {
+    Items:  list,
+    Total:  total,
+    Limit:  limit,
+    Offset: offset,
+  })
+}
+
+// paginationLinks builds an RFC 5988 Link header value carrying "next"/"prev"
+// relations, omitting whichever does not apply at the current offset.
+func paginationLinks (rq *http.Request, limit, offset, total int) string {
+  build := func (o int) string {
+    u := *rq.URL
+    v := url.Values{}
+    for key, vals := range rq.URL.Query() {
+      v[key] = vals
+    }
+    v.Set("limit", strconv.Itoa(limit))
+    v.Set("offset", strconv.Itoa(o))
+    u.RawQuery = v.Encode()
+    return u.String()
+  }
+
+  var links []string
+  if offset + limit < total {
+    links = append(links, fmt.Sprintf(`<%s>; rel="next"`, build(offset + limit)))
+  }
+  if offset > 0 {
+    prevOffset := offset - limit
+    if prevOffset < 0 {
+      prevOffset = 0
+    }
+    links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, build(prevOffset)))
+  }
+
+  return strings.Join(links, ", ")
 }
 
 func (c *ListController) Post (x context.Context, rq *http.Request, re *route.Result) error {
@@ -496,3 +1257,127 @@ func (c *ListController) Put (x context.Context, rq *http.Request, re *route.Res
 func (c *ListController) Delete (x context.Context, rq *http.Request, re *route.Result) error {
   return re.Unimplemented()
 }
+
+
+// TagsController
+
+type TagCount struct {
+  Name  string `json:"name"`
+  Count int    `json:"count"`
+}
+
+func (c *TagsController) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  var (
+    entry TagCount
+    list  []TagCount
+  )
+
+  q := fmt.Sprintf("SELECT t.name, COUNT(pt.page_id) AS count " +
+                   "FROM %s AS t LEFT JOIN %s AS pt ON pt.tag_id = t.id " +
+                   "GROUP BY t.id, t.name " +
+                   "ORDER BY count DESC, t.name", c.Data.TagTable, c.Data.TagJoinTable)
+
+  // Extract rows
+  rows, err := c.Service.Database.DB.Query(q)
+  if nil != err {
+    return err
+  }
+  defer rows.Close()
+
+  // Marshal rows
+  for rows.Next() {
+    if err = rows.Scan(&entry.Name, &entry.Count); nil != err {
+      break
+    }
+    list = append(list, entry)
+  }
+
+  // Check error
+  if nil != err {
+    return err
+  }
+
+  // Write to buffer and return any encoding error
+  return re.Marshal(route.ContentTypeJSON, &list)
+}
+
+func (c *TagsController) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *TagsController) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *TagsController) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+
+// RevisionsController
+
+type RevisionEntry struct {
+  Revision int    `json:"revision"`
+  Title    string `json:"title"`
+  Subtitle string `json:"subtitle"`
+  EditedAt string `json:"edited_at"`
+  EditedBy string `json:"edited_by"`
+}
+
+func (c *RevisionsController) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  var (
+    id    string = rq.URL.Query().Get("id")
+    entry RevisionEntry
+    list  []RevisionEntry
+    err   error
+  )
+
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  if "" == id {
+    return fail(fmt.Errorf("Invalid query parameter"), http.StatusBadRequest)
+  }
+
+  q := fmt.Sprintf("SELECT revision_no, title, subtitle, edited_at, edited_by " +
+                   "FROM %s WHERE page_id = ? ORDER BY revision_no DESC", c.Data.RevisionTable)
+
+  // Extract rows
+  rows, err := c.Service.Database.DB.Query(q, id)
+  if nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+  defer rows.Close()
+
+  // Marshal rows
+  for rows.Next() {
+    var editedAt time.Time
+    if err = rows.Scan(&entry.Revision, &entry.Title, &entry.Subtitle, &editedAt,
+      &entry.EditedBy); nil != err {
+        break
+      }
+    entry.EditedAt = editedAt.Format(c.Data.TimeFormat)
+    list = append(list, entry)
+  }
+
+  // Check error
+  if nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  return re.Marshal(route.ContentTypeJSON, &list)
+}
+
+func (c *RevisionsController) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *RevisionsController) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *RevisionsController) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
@@ -0,0 +1,145 @@
+// Package debug exposes small operator endpoints that are not part of the
+// public API surface, guarded by the same session auth as everything else.
+package debug
+
+import (
+  "context"
+  "micrified.com/internal/user"
+  "micrified.com/route"
+  "net/http"
+  "time"
+)
+
+
+/*\
+ *******************************************************************************
+ *                                  Constants                                  *
+ *******************************************************************************
+\*/
+
+
+const (
+  RouteSessionsCheckpointName string = "debug/sessions/checkpoint"
+)
+
+
+/*\
+ *******************************************************************************
+ *                              Type Definitions                               *
+ *******************************************************************************
+\*/
+
+
+// Data: Debug
+type debugDataType struct {
+  SessionFile string
+}
+
+// Controller: Force a session checkpoint flush
+type SessionsCheckpointController route.ControllerType[debugDataType]
+
+
+/*\
+ *******************************************************************************
+ *                              Global Variables                               *
+ *******************************************************************************
+\*/
+
+
+var debugData debugDataType = debugDataType {
+  SessionFile: "sessions.dat",
+}
+
+
+/*\
+ *******************************************************************************
+ *                                Constructors                                 *
+ *******************************************************************************
+\*/
+
+
+func NewSessionsCheckpointController (s route.Service) SessionsCheckpointController {
+  return SessionsCheckpointController {
+    Name:    RouteSessionsCheckpointName,
+    Methods: map[string]route.Method {
+      http.MethodGet: route.Restful.Get,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data:    debugData,
+  }
+}
+
+
+/*\
+ *******************************************************************************
+ *                            Interface: Controller                            *
+ *******************************************************************************
+\*/
+
+
+func (c *SessionsCheckpointController) Route () string {
+  return "/" + c.Name
+}
+
+func (c *SessionsCheckpointController) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *SessionsCheckpointController) Timeout () time.Duration {
+  return c.Limit
+}
+
+
+/*\
+ *******************************************************************************
+ *                             Interface: Restful                              *
+ *******************************************************************************
+\*/
+
+
+type CheckpointResponse struct {
+  Path string `json:"path"`
+}
+
+func (c *SessionsCheckpointController) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  var (
+    ip       string = x.Value(user.UserIPKey).(string)
+    username string = rq.URL.Query().Get("username")
+    secret   string = rq.URL.Query().Get("secret")
+  )
+
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  // Check if authorized
+  if err := c.Service.Auth.Authorized(ip, username, secret); nil != err {
+    return fail(err, http.StatusUnauthorized)
+  }
+
+  // Force an out-of-band checkpoint flush on top of the periodic one
+  if err := c.Service.Auth.Checkpoint(c.Data.SessionFile); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  return re.Marshal(route.ContentTypeJSON, &CheckpointResponse {
+    Path: c.Data.SessionFile,
+  })
+}
+
+func (c *SessionsCheckpointController) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *SessionsCheckpointController) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *SessionsCheckpointController) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
@@ -0,0 +1,144 @@
+// Package health exposes a liveness/readiness endpoint for load balancers
+// and uptime checks.
+package health
+
+import (
+  "context"
+  "micrified.com/route"
+  "net/http"
+  "time"
+)
+
+
+/*\
+ *******************************************************************************
+ *                                  Constants                                  *
+ *******************************************************************************
+\*/
+
+
+const (
+  RouteName string = "health"
+)
+
+
+/*\
+ *******************************************************************************
+ *                              Type Definitions                               *
+ *******************************************************************************
+\*/
+
+
+// Data: Health
+type healthDataType struct {
+  PingTimeout time.Duration
+}
+
+// Controller: Health
+type Controller route.ControllerType[healthDataType]
+
+
+/*\
+ *******************************************************************************
+ *                              Global Variables                               *
+ *******************************************************************************
+\*/
+
+
+var healthData healthDataType = healthDataType {
+  PingTimeout: 2 * time.Second,
+}
+
+// startTime marks process start, for reporting uptime.
+var startTime time.Time = time.Now()
+
+
+/*\
+ *******************************************************************************
+ *                                Constructors                                 *
+ *******************************************************************************
+\*/
+
+
+func NewController (s route.Service) Controller {
+  return Controller {
+    Name:    RouteName,
+    Methods: map[string]route.Method {
+      http.MethodGet: route.Restful.Get,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data:    healthData,
+  }
+}
+
+
+/*\
+ *******************************************************************************
+ *                            Interface: Controller                            *
+ *******************************************************************************
+\*/
+
+
+func (c *Controller) Route () string {
+  return "/" + c.Name
+}
+
+func (c *Controller) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *Controller) Timeout () time.Duration {
+  return c.Limit
+}
+
+
+/*\
+ *******************************************************************************
+ *                             Interface: Restful                              *
+ *******************************************************************************
+\*/
+
+
+type HealthResponse struct {
+  Status string `json:"status"`
+  DB     string `json:"db"`
+  Uptime string `json:"uptime"`
+}
+
+func (c *Controller) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  dbStatus := "ok"
+
+  pingContext, cancel := context.WithTimeout(x, c.Data.PingTimeout)
+  defer cancel()
+
+  if err := c.Service.Database.DB.PingContext(pingContext); nil != err {
+    dbStatus = "fail"
+  }
+
+  status := "ok"
+  if "ok" != dbStatus {
+    status = "degraded"
+  }
+
+  return re.Marshal(route.ContentTypeJSON, &HealthResponse {
+    Status: status,
+    DB:     dbStatus,
+    Uptime: time.Since(startTime).String(),
+  })
+}
+
+func (c *Controller) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *Controller) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *Controller) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
@@ -2,12 +2,14 @@ package login
 
 import (
   "context"
+  "database/sql"
   "encoding/json"
   "fmt"
   "io/ioutil"
   "micrified.com/internal/user"
   "micrified.com/route"
   "micrified.com/service/auth"
+  "micrified.com/service/metrics"
   "net/http"
   "time"
 )
@@ -30,6 +32,14 @@ type Controller route.ControllerType[loginData]
 
 
 func NewController (s route.Service) Controller {
+  data := loginData {
+    TimeFormat:      "2006-01-02 15:04:05",
+    UserTable:       "users",
+    CredentialTable: "credentials",
+  }
+
+  ensureAlgoColumn(s, data)
+
   return Controller {
     Name:             "login",
     Methods: map[string]route.Method {
@@ -37,14 +47,23 @@ func NewController (s route.Service) Controller {
     },
     Service:           s,
     Limit:             5 * time.Second,
-    Data: loginData {
-      TimeFormat:      "2006-01-02 15:04:05",
-      UserTable:       "users",
-      CredentialTable: "credentials",
-    },
+    Data:              data,
   }
 }
 
+// ensureAlgoColumn adds the column tracking which hashing algorithm
+// produced a credential the first time Controller is constructed against
+// a database that predates transparent rehashing; it is a no-op on every
+// startup after that. Pre-existing rows are backfilled with auth.LegacyAlgo,
+// not auth.DefaultAlgo(), since they were hashed before Argon2id existed;
+// labelling them Argon2id would make Compare reject every one of them, and
+// NeedsRehash upgrades each to DefaultAlgo() on its next successful login.
+func ensureAlgoColumn (s route.Service, data loginData) {
+  s.Database.DB.Exec(fmt.Sprintf(
+    "ALTER TABLE %s ADD COLUMN IF NOT EXISTS algo VARCHAR(64) NOT NULL DEFAULT '%s'",
+    data.CredentialTable, auth.LegacyAlgo))
+}
+
 func (c *Controller) Route () string {
   return "/" + c.Name
 }
@@ -80,6 +99,7 @@ type LoginCredential struct {
 
 type StoredCredential struct {
   Hash, Salt []byte
+  Algo       string
 }
 
 type SessionCredential struct {
@@ -112,20 +132,22 @@ func (c *Controller) Post (x context.Context, rq *http.Request, re *route.Result
 
   // Check if a retry penalty exists (IP must exist)
   if c.Service.Auth.Penalised(ip) {
+    metrics.Incr("login.penalized")
     return fail(fmt.Errorf("Try again later"), http.StatusTooManyRequests)
   }
 
   // Extract stored login credentials
-  q := fmt.Sprintf("SELECT b.hash, b.salt " +
+  q := fmt.Sprintf("SELECT b.hash, b.salt, b.algo " +
                    "FROM %s AS a INNER JOIN %s AS b " +
 		   "ON a.id = b.user_id " +
-		   "WHERE a.username = ?", 
+		   "WHERE a.username = ?",
 		   c.Data.UserTable, c.Data.CredentialTable)
 
+  // Populated by doAuth so a post-success rehash knows what algo to replace
+  var stored StoredCredential
+
   // Define the authentication routine
   doAuth := func () (bool, error) {
-    var stored StoredCredential
-
     rows, err := c.Service.Database.DB.Query(q, login.Username)
     if nil != err {
       return false, err
@@ -134,15 +156,15 @@ func (c *Controller) Post (x context.Context, rq *http.Request, re *route.Result
       fmt.Println("No account")
       return false, nil
     }
-    if err = rows.Scan(&stored.Hash, &stored.Salt); nil != err {
+    if err = rows.Scan(&stored.Hash, &stored.Salt, &stored.Algo); nil != err {
       return false, err
     }
     fmt.Println("Comparing credentials ...")
-    return auth.Compare(login.Passphrase, stored.Salt, stored.Hash), nil
+    return auth.Compare(login.Passphrase, stored.Algo, stored.Salt, stored.Hash), nil
   }
 
-  // Perform authentication 
-  session, ok, err := c.Service.Auth.Authenticate(ip, login.Username, 
+  // Perform authentication
+  session, ok, err := c.Service.Auth.Authenticate(ip, login.Username,
     login.Period, doAuth)
   if err != nil {
     // TODO: Don't leak info here
@@ -152,11 +174,28 @@ func (c *Controller) Post (x context.Context, rq *http.Request, re *route.Result
   // Wipe penalty and create session if OK; else penalise and return error
   if ok {
     c.Service.Auth.NoPenalty(ip)
+    metrics.Incr("login.success")
   } else {
     c.Service.Auth.Penalise(ip)
+    metrics.Incr("login.fail")
     return fail(fmt.Errorf("Bad credentials"), http.StatusUnauthorized)
   }
 
+  // Roll the stored credential forward to the current algorithm now that
+  // the plaintext passphrase is known to be correct; never blocks the login.
+  if auth.NeedsRehash(stored.Algo) {
+    if newAlgo, newSalt, newHash, rehashErr := auth.Hash(login.Passphrase); nil == rehashErr {
+      rehash := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+        q := fmt.Sprintf("UPDATE %s SET hash = ?, salt = ?, algo = ? " +
+                         "WHERE user_id = (SELECT id FROM %s WHERE username = ?)",
+			 c.Data.CredentialTable, c.Data.UserTable)
+        return t.ExecContext(c.Service.Database.Context, q, newHash, newSalt, newAlgo,
+          login.Username)
+      }
+      c.Service.Database.Transaction(rehash)
+    }
+  }
+
   // Compose response
   fmt.Printf("Response should be OK: %+v\n", re)
   return re.Marshal(route.ContentTypeJSON,
@@ -0,0 +1,145 @@
+package logout
+
+import (
+  "context"
+  "encoding/json"
+  "fmt"
+  "io/ioutil"
+  "micrified.com/internal/user"
+  "micrified.com/route"
+  "net/http"
+  "time"
+)
+
+
+/*\
+ *******************************************************************************
+ *                              Type Definitions                               *
+ *******************************************************************************
+\*/
+
+
+// Data: Logout
+type logoutData struct {
+  TimeFormat string
+}
+
+// Controller: Logout
+type Controller route.ControllerType[logoutData]
+
+
+/*\
+ *******************************************************************************
+ *                                Constructors                                 *
+ *******************************************************************************
+\*/
+
+
+func NewController (s route.Service) Controller {
+  return Controller {
+    Name:    "logout",
+    Methods: map[string]route.Method {
+      http.MethodPost: route.Restful.Post,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data: logoutData {
+      TimeFormat: "2006-01-02 15:04:05",
+    },
+  }
+}
+
+
+/*\
+ *******************************************************************************
+ *                            Interface: Controller                            *
+ *******************************************************************************
+\*/
+
+
+func (c *Controller) Route () string {
+  return "/" + c.Name
+}
+
+func (c *Controller) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *Controller) Timeout () time.Duration {
+  return c.Limit
+}
+
+
+/*\
+ *******************************************************************************
+ *                             Interface: Restful                              *
+ *******************************************************************************
+\*/
+
+
+func (c *Controller) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+type LogoutCredential struct {
+  Username string `json:"username"`
+  Secret   string `json:"secret"`
+}
+
+func (c *Controller) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  var (
+    body   []byte           = []byte{}
+    err    error            = nil
+    ip     string           = x.Value(user.UserIPKey).(string)
+    logout LogoutCredential = LogoutCredential{}
+  )
+
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  // Read request body
+  if body, err = ioutil.ReadAll(rq.Body); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Unmarshal to type
+  if err = json.Unmarshal(body, &logout); nil != err {
+    return fail(err, http.StatusBadRequest)
+  }
+
+  if "" == logout.Username || "" == logout.Secret {
+    return fail(fmt.Errorf("Missing username or secret"), http.StatusBadRequest)
+  }
+
+  // The presented secret must itself be a live session before it can be
+  // used to revoke anything, including every other session on ?all=1
+  if err = c.Service.Auth.Authorized(ip, logout.Username, logout.Secret); nil != err {
+    return fail(err, http.StatusUnauthorized)
+  }
+
+  // Revoke every session when asked to, otherwise just the one presented
+  if "1" == rq.URL.Query().Get("all") {
+    err = c.Service.Auth.RevokeAll(logout.Username)
+  } else {
+    err = c.Service.Auth.Revoke(logout.Username, logout.Secret)
+  }
+  if nil != err {
+    return fail(err, http.StatusUnauthorized)
+  }
+
+  re.Status = http.StatusNoContent
+  return nil
+}
+
+func (c *Controller) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *Controller) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
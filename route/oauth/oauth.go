@@ -0,0 +1,507 @@
+// Package oauth exposes a standard OAuth2 authorization-code and
+// client-credentials surface on top of the existing session-backed auth
+// service, so third-party apps can integrate without speaking the
+// project's custom {userid, passphrase, period} login protocol.
+package oauth
+
+import (
+  "context"
+  "crypto/rand"
+  "crypto/sha256"
+  "database/sql"
+  "encoding/hex"
+  "encoding/json"
+  "fmt"
+  "io/ioutil"
+  "micrified.com/internal/user"
+  "micrified.com/route"
+  "micrified.com/service/auth"
+  "net/http"
+  "net/url"
+  "strings"
+  "time"
+)
+
+
+/*\
+ *******************************************************************************
+ *                                  Constants                                  *
+ *******************************************************************************
+\*/
+
+
+const (
+  RouteAuthorizeName string = "oauth/authorize"
+  RouteTokenName     string = "oauth/token"
+  RouteUserinfoName  string = "oauth/userinfo"
+
+  // CodeLength is the number of random bytes making up an authorization code
+  CodeLength int = 32
+
+  // CodeLifetime bounds how long an issued code remains exchangeable
+  CodeLifetime time.Duration = 5 * time.Minute
+
+  // BearerPeriod is the period handed to the same session issuer that
+  // Authenticate uses, so bearer tokens expire like any other session.
+  BearerPeriod string = "1h"
+)
+
+
+/*\
+ *******************************************************************************
+ *                              Type Definitions                               *
+ *******************************************************************************
+\*/
+
+
+// Data: OAuth
+type oauthDataType struct {
+  TimeFormat, ClientTable, CodeTable string
+}
+
+// Controller: Authorization endpoint
+type AuthorizeController route.ControllerType[oauthDataType]
+
+// Controller: Token endpoint
+type TokenController route.ControllerType[oauthDataType]
+
+// Controller: Userinfo endpoint
+type UserinfoController route.ControllerType[oauthDataType]
+
+
+/*\
+ *******************************************************************************
+ *                              Global Variables                               *
+ *******************************************************************************
+\*/
+
+
+var oauthData oauthDataType = oauthDataType {
+  TimeFormat:  "2006-01-02 15:04:05",
+  ClientTable: "clients",
+  CodeTable:   "oauth_codes",
+}
+
+
+/*\
+ *******************************************************************************
+ *                                Constructors                                 *
+ *******************************************************************************
+\*/
+
+
+func NewAuthorizeController (s route.Service) AuthorizeController {
+  ensureClientTable(s)
+  ensureCodeTable(s)
+
+  return AuthorizeController {
+    Name:    RouteAuthorizeName,
+    Methods: map[string]route.Method {
+      http.MethodGet: route.Restful.Get,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data:    oauthData,
+  }
+}
+
+func NewTokenController (s route.Service) TokenController {
+  ensureClientTable(s)
+  ensureCodeTable(s)
+
+  return TokenController {
+    Name:    RouteTokenName,
+    Methods: map[string]route.Method {
+      http.MethodPost: route.Restful.Post,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data:    oauthData,
+  }
+}
+
+func NewUserinfoController (s route.Service) UserinfoController {
+  return UserinfoController {
+    Name:    RouteUserinfoName,
+    Methods: map[string]route.Method {
+      http.MethodGet: route.Restful.Get,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data:    oauthData,
+  }
+}
+
+// ensureClientTable creates the table backing registered OAuth2 clients the
+// first time either controller is constructed against a database that
+// predates it; it is a no-op on every startup after that.
+func ensureClientTable (s route.Service) {
+  s.Database.DB.Exec(fmt.Sprintf(
+    "CREATE TABLE IF NOT EXISTS %s (" +
+    "client_id VARCHAR(64) NOT NULL, client_secret_hash VARBINARY(255) NOT NULL, " +
+    "salt VARBINARY(255) NOT NULL, algo VARCHAR(64) NOT NULL, " +
+    "redirect_uris TEXT NOT NULL, scopes TEXT NOT NULL, " +
+    "PRIMARY KEY (client_id))", oauthData.ClientTable))
+}
+
+// ensureCodeTable creates the table backing issued authorization codes the
+// first time either controller is constructed against a database that
+// predates it; it is a no-op on every startup after that.
+func ensureCodeTable (s route.Service) {
+  s.Database.DB.Exec(fmt.Sprintf(
+    "CREATE TABLE IF NOT EXISTS %s (" +
+    "code_hash VARCHAR(64) NOT NULL, client_id VARCHAR(64) NOT NULL, " +
+    "redirect_uri VARCHAR(2048) NOT NULL, scope VARCHAR(512) NOT NULL, " +
+    "username VARCHAR(255) NOT NULL, expires_at DATETIME NOT NULL, " +
+    "used_at DATETIME NULL, PRIMARY KEY (code_hash))", oauthData.CodeTable))
+}
+
+
+/*\
+ *******************************************************************************
+ *                            Interface: Controller                            *
+ *******************************************************************************
+\*/
+
+
+// AuthorizeController
+
+func (c *AuthorizeController) Route () string {
+  return "/" + c.Name
+}
+
+func (c *AuthorizeController) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *AuthorizeController) Timeout () time.Duration {
+  return c.Limit
+}
+
+
+// TokenController
+
+func (c *TokenController) Route () string {
+  return "/" + c.Name
+}
+
+func (c *TokenController) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *TokenController) Timeout () time.Duration {
+  return c.Limit
+}
+
+
+// UserinfoController
+
+func (c *UserinfoController) Route () string {
+  return "/" + c.Name
+}
+
+func (c *UserinfoController) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *UserinfoController) Timeout () time.Duration {
+  return c.Limit
+}
+
+
+/*\
+ *******************************************************************************
+ *                       Interface: Restful (Authorize)                        *
+ *******************************************************************************
+\*/
+
+
+// hasRedirectURI reports whether uri is one of a client's registered
+// redirect URIs.
+func hasRedirectURI (uris []string, uri string) bool {
+  for _, candidate := range uris {
+    if candidate == uri {
+      return true
+    }
+  }
+  return false
+}
+
+// scopeSubset reports whether every space-separated scope token in
+// requested is among a client's registered scopes, so a client can never
+// be issued a code or token for more than it was registered for.
+func scopeSubset (granted []string, requested string) bool {
+  grantedSet := make(map[string]bool, len(granted))
+  for _, scope := range granted {
+    grantedSet[scope] = true
+  }
+  for _, scope := range strings.Fields(requested) {
+    if !grantedSet[scope] {
+      return false
+    }
+  }
+  return true
+}
+
+type ConsentResponse struct {
+  RedirectURI string `json:"redirect_uri"`
+  Code        string `json:"code"`
+  State       string `json:"state,omitempty"`
+}
+
+// Get renders the data a client-side consent form needs and, since the
+// caller already presented a valid session (username/secret), issues the
+// short-lived code in the same request rather than requiring a second
+// confirmation round-trip.
+func (c *AuthorizeController) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  var (
+    ip          string     = x.Value(user.UserIPKey).(string)
+    query       url.Values = rq.URL.Query()
+    clientID    string     = query.Get("client_id")
+    redirectURI string     = query.Get("redirect_uri")
+    scope       string     = query.Get("scope")
+    state       string     = query.Get("state")
+    username    string     = query.Get("username")
+    secret      string     = query.Get("secret")
+  )
+
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  if "code" != query.Get("response_type") {
+    return fail(fmt.Errorf("Unsupported response_type"), http.StatusBadRequest)
+  }
+
+  // Check if authorized
+  if err := c.Service.Auth.Authorized(ip, username, secret); nil != err {
+    return fail(err, http.StatusUnauthorized)
+  }
+
+  client, err := c.Service.Clients.GetByID(x, clientID)
+  if nil != err {
+    return fail(fmt.Errorf("Unknown client"), http.StatusBadRequest)
+  }
+  if !hasRedirectURI(client.RedirectURIs, redirectURI) {
+    return fail(fmt.Errorf("Redirect URI not registered"), http.StatusBadRequest)
+  }
+  if !scopeSubset(client.Scopes, scope) {
+    return fail(fmt.Errorf("Scope exceeds client registration"), http.StatusBadRequest)
+  }
+
+  // Generate a single-use code and store only its hash, bound to the
+  // client, redirect URI, scope, and approving user
+  raw := make([]byte, CodeLength)
+  if _, err = rand.Read(raw); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+  code := hex.EncodeToString(raw)
+  sum := sha256.Sum256([]byte(code))
+  codeHash := hex.EncodeToString(sum[:])
+
+  insertCode := func (lastResult sql.Result, conn *sql.Conn) (sql.Result, error) {
+    q := fmt.Sprintf("INSERT INTO %s (code_hash,client_id,redirect_uri,scope,username,expires_at) " +
+                     "VALUES (?,?,?,?,?,?)", c.Data.CodeTable)
+    return conn.ExecContext(c.Service.Database.Context, q, codeHash, clientID, redirectURI,
+      scope, username, time.Now().UTC().Add(CodeLifetime))
+  }
+
+  if _, err = c.Service.Database.Connection(insertCode); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  return re.Marshal(route.ContentTypeJSON, &ConsentResponse {
+    RedirectURI: redirectURI,
+    Code:        code,
+    State:       state,
+  })
+}
+
+func (c *AuthorizeController) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *AuthorizeController) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *AuthorizeController) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+
+/*\
+ *******************************************************************************
+ *                         Interface: Restful (Token)                          *
+ *******************************************************************************
+\*/
+
+
+func (c *TokenController) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+type TokenRequest struct {
+  GrantType    string `json:"grant_type"`
+  Code         string `json:"code"`
+  RedirectURI  string `json:"redirect_uri"`
+  ClientID     string `json:"client_id"`
+  ClientSecret string `json:"client_secret"`
+  Scope        string `json:"scope"`
+}
+
+type TokenResponse struct {
+  AccessToken string `json:"access_token"`
+  TokenType   string `json:"token_type"`
+  ExpiresIn   int64  `json:"expires_in"`
+  Scope       string `json:"scope,omitempty"`
+}
+
+func (c *TokenController) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  var (
+    body     []byte       = []byte{}
+    err      error        = nil
+    request  TokenRequest = TokenRequest{}
+    username string
+    scope    string
+  )
+
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  // Read request body
+  if body, err = ioutil.ReadAll(rq.Body); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Unmarshal to type
+  if err = json.Unmarshal(body, &request); nil != err {
+    return fail(err, http.StatusBadRequest)
+  }
+
+  // Every grant starts with authenticating the calling client
+  client, err := c.Service.Clients.GetByID(x, request.ClientID)
+  if nil != err || !auth.Compare(request.ClientSecret, client.Algo, client.Salt, client.SecretHash) {
+    return fail(fmt.Errorf("Invalid client credentials"), http.StatusUnauthorized)
+  }
+
+  switch request.GrantType {
+  case "authorization_code":
+    sum := sha256.Sum256([]byte(request.Code))
+    codeHash := hex.EncodeToString(sum[:])
+
+    var storedRedirectURI string
+    q := fmt.Sprintf("SELECT username, redirect_uri, scope FROM %s " +
+                     "WHERE code_hash = ? AND client_id = ? AND used_at IS NULL AND expires_at > ?",
+		     c.Data.CodeTable)
+    if err = c.Service.Database.DB.QueryRow(q, codeHash, request.ClientID,
+      time.Now().UTC()).Scan(&username, &storedRedirectURI, &scope); nil != err {
+      return fail(fmt.Errorf("Invalid or expired code"), http.StatusBadRequest)
+    }
+    if storedRedirectURI != request.RedirectURI {
+      return fail(fmt.Errorf("Redirect URI mismatch"), http.StatusBadRequest)
+    }
+
+    markUsed := func (lastResult sql.Result, conn *sql.Conn) (sql.Result, error) {
+      q := fmt.Sprintf("UPDATE %s SET used_at = ? WHERE code_hash = ?", c.Data.CodeTable)
+      return conn.ExecContext(c.Service.Database.Context, q, time.Now().UTC(), codeHash)
+    }
+    if _, err = c.Service.Database.Connection(markUsed); nil != err {
+      return fail(err, http.StatusInternalServerError)
+    }
+
+  case "client_credentials":
+    if !scopeSubset(client.Scopes, request.Scope) {
+      return fail(fmt.Errorf("Scope exceeds client registration"), http.StatusBadRequest)
+    }
+
+    // The client acts on its own behalf; there is no end user
+    username = "client:" + client.ID
+    scope = request.Scope
+
+  default:
+    return fail(fmt.Errorf("Unsupported grant_type"), http.StatusBadRequest)
+  }
+
+  // Mint a bearer token with the same secret generator that backs
+  // session.Secret, so it is validated and revoked the same way
+  session, err := c.Service.Auth.IssueSession(username, BearerPeriod)
+  if nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  return re.Marshal(route.ContentTypeJSON, &TokenResponse {
+    AccessToken: session.Secret.HexString(),
+    TokenType:   "Bearer",
+    ExpiresIn:   int64(time.Until(session.Expiration).Seconds()),
+    Scope:       scope,
+  })
+}
+
+func (c *TokenController) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *TokenController) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+
+/*\
+ *******************************************************************************
+ *                       Interface: Restful (Userinfo)                         *
+ *******************************************************************************
+\*/
+
+
+type UserinfoResponse struct {
+  Subject    string `json:"sub"`
+  Expiration string `json:"exp"`
+}
+
+func (c *UserinfoController) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  authorization := rq.Header.Get("Authorization")
+  if !strings.HasPrefix(authorization, "Bearer ") {
+    return fail(fmt.Errorf("Missing bearer token"), http.StatusUnauthorized)
+  }
+  token := strings.TrimPrefix(authorization, "Bearer ")
+
+  session, err := c.Service.Auth.LookupBearer(token)
+  if nil != err {
+    return fail(err, http.StatusUnauthorized)
+  }
+
+  return re.Marshal(route.ContentTypeJSON, &UserinfoResponse {
+    Subject:    session.Username,
+    Expiration: session.Expiration.Format(c.Data.TimeFormat),
+  })
+}
+
+func (c *UserinfoController) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *UserinfoController) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *UserinfoController) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
@@ -0,0 +1,376 @@
+// Package pwreset implements the password reset workflow: a caller requests
+// a one-time token by username, and later confirms it along with a new
+// passphrase. Both steps are throttled per source IP the same way login is.
+package pwreset
+
+import (
+  "context"
+  "crypto/rand"
+  "crypto/sha256"
+  "database/sql"
+  "encoding/hex"
+  "encoding/json"
+  "fmt"
+  "io/ioutil"
+  "micrified.com/internal/user"
+  "micrified.com/route"
+  "micrified.com/service/auth"
+  "net/http"
+  "time"
+)
+
+
+/*\
+ *******************************************************************************
+ *                                  Constants                                  *
+ *******************************************************************************
+\*/
+
+
+const (
+  RouteRequestName string = "pwreset/request"
+  RouteConfirmName string = "pwreset/confirm"
+
+  // TokenLength is the number of random bytes making up a reset token
+  TokenLength int = 32
+
+  // TokenLifetime bounds how long an issued token remains usable
+  TokenLifetime time.Duration = 1 * time.Hour
+
+  // MinPassphraseLength is the shortest NewPassphrase ConfirmController.Post
+  // will accept
+  MinPassphraseLength int = 8
+)
+
+
+/*\
+ *******************************************************************************
+ *                              Type Definitions                               *
+ *******************************************************************************
+\*/
+
+
+// Data: Password reset
+type pwresetDataType struct {
+  TimeFormat, UserTable, CredentialTable, ResetTable string
+}
+
+// Controller: Password reset request
+type RequestController route.ControllerType[pwresetDataType]
+
+// Controller: Password reset confirm
+type ConfirmController route.ControllerType[pwresetDataType]
+
+
+/*\
+ *******************************************************************************
+ *                              Global Variables                               *
+ *******************************************************************************
+\*/
+
+
+var pwresetData pwresetDataType = pwresetDataType {
+  TimeFormat:      "2006-01-02 15:04:05",
+  UserTable:       "users",
+  CredentialTable: "credentials",
+  ResetTable:      "password_resets",
+}
+
+
+/*\
+ *******************************************************************************
+ *                                Constructors                                 *
+ *******************************************************************************
+\*/
+
+
+func NewRequestController (s route.Service) RequestController {
+  ensureResetTable(s)
+  ensureEmailColumn(s)
+
+  return RequestController {
+    Name:    RouteRequestName,
+    Methods: map[string]route.Method {
+      http.MethodPost: route.Restful.Post,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data:    pwresetData,
+  }
+}
+
+func NewConfirmController (s route.Service) ConfirmController {
+  ensureResetTable(s)
+
+  return ConfirmController {
+    Name:    RouteConfirmName,
+    Methods: map[string]route.Method {
+      http.MethodPost: route.Restful.Post,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data:    pwresetData,
+  }
+}
+
+// ensureResetTable creates the table backing issued reset tokens the first
+// time either controller is constructed against a database that predates
+// it; it is a no-op on every startup after that.
+func ensureResetTable (s route.Service) {
+  s.Database.DB.Exec(fmt.Sprintf(
+    "CREATE TABLE IF NOT EXISTS %s (" +
+    "user_id INT NOT NULL, token_hash VARCHAR(64) NOT NULL, " +
+    "expires_at DATETIME NOT NULL, used_at DATETIME NULL, " +
+    "PRIMARY KEY (token_hash))", pwresetData.ResetTable))
+}
+
+// ensureEmailColumn adds the email column the first time RequestController
+// is constructed against a database that predates it; it is a no-op on
+// every startup after that.
+func ensureEmailColumn (s route.Service) {
+  s.Database.DB.Exec(fmt.Sprintf(
+    "ALTER TABLE %s ADD COLUMN IF NOT EXISTS email VARCHAR(320) NOT NULL DEFAULT ''",
+    pwresetData.UserTable))
+}
+
+
+/*\
+ *******************************************************************************
+ *                            Interface: Controller                            *
+ *******************************************************************************
+\*/
+
+
+// RequestController
+
+func (c *RequestController) Route () string {
+  return "/" + c.Name
+}
+
+func (c *RequestController) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *RequestController) Timeout () time.Duration {
+  return c.Limit
+}
+
+
+// ConfirmController
+
+func (c *ConfirmController) Route () string {
+  return "/" + c.Name
+}
+
+func (c *ConfirmController) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *ConfirmController) Timeout () time.Duration {
+  return c.Limit
+}
+
+
+/*\
+ *******************************************************************************
+ *                             Interface: Restful                              *
+ *******************************************************************************
+\*/
+
+
+// RequestController
+
+func (c *RequestController) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+type ResetRequest struct {
+  Username string `json:"username"`
+}
+
+func (c *RequestController) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  var (
+    body    []byte       = []byte{}
+    err     error        = nil
+    ip      string       = x.Value(user.UserIPKey).(string)
+    request ResetRequest = ResetRequest{}
+  )
+
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  // Respond 200 on malformed input and whether or not the username exists,
+  // to avoid leaking which usernames exist. A penalised IP still gets 429,
+  // and internal failures still get 500.
+  respondOK := func () error {
+    re.Status = http.StatusOK
+    return nil
+  }
+
+  // Check if a retry penalty exists (IP must exist)
+  if c.Service.Auth.Penalised(ip) {
+    return fail(fmt.Errorf("Try again later"), http.StatusTooManyRequests)
+  }
+
+  // Read request body
+  if body, err = ioutil.ReadAll(rq.Body); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Unmarshal to type
+  if err = json.Unmarshal(body, &request); nil != err {
+    c.Service.Auth.Penalise(ip)
+    return respondOK()
+  }
+
+  // Look up the account; silently succeed if it doesn't exist
+  var (
+    userID int64
+    email  string
+  )
+  q := fmt.Sprintf("SELECT id, email FROM %s WHERE username = ?", c.Data.UserTable)
+  if err = c.Service.Database.DB.QueryRow(q, request.Username).Scan(&userID, &email); nil != err {
+    c.Service.Auth.Penalise(ip)
+    return respondOK()
+  }
+
+  // Generate a single-use token and store only its hash
+  raw := make([]byte, TokenLength)
+  if _, err = rand.Read(raw); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+  token := hex.EncodeToString(raw)
+  sum := sha256.Sum256([]byte(token))
+  tokenHash := hex.EncodeToString(sum[:])
+
+  insertToken := func (lastResult sql.Result, conn *sql.Conn) (sql.Result, error) {
+    q := fmt.Sprintf("INSERT INTO %s (user_id,token_hash,expires_at) VALUES (?,?,?)",
+      c.Data.ResetTable)
+    return conn.ExecContext(c.Service.Database.Context, q, userID, tokenHash,
+      time.Now().UTC().Add(TokenLifetime))
+  }
+
+  if _, err = c.Service.Database.Connection(insertToken); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Dispatch the token by mail; failure here is not revealed to the caller.
+  // An account with no email on file has nowhere to send the token, so it
+  // is skipped the same way a nonexistent account is above.
+  if "" != email {
+    c.Service.Mail.Send(email, "Password reset",
+      fmt.Sprintf("Use this token to reset your password: %s\n"+
+        "It expires in %s.", token, TokenLifetime))
+  }
+
+  c.Service.Auth.NoPenalty(ip)
+  return respondOK()
+}
+
+func (c *RequestController) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *RequestController) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+
+// ConfirmController
+
+func (c *ConfirmController) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+type ResetConfirm struct {
+  Token         string `json:"token"`
+  NewPassphrase string `json:"new_passphrase"`
+}
+
+func (c *ConfirmController) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  var (
+    body    []byte       = []byte{}
+    err     error        = nil
+    ip      string       = x.Value(user.UserIPKey).(string)
+    confirm ResetConfirm = ResetConfirm{}
+    userID  int64
+  )
+
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  // Check if a retry penalty exists (IP must exist)
+  if c.Service.Auth.Penalised(ip) {
+    return fail(fmt.Errorf("Try again later"), http.StatusTooManyRequests)
+  }
+
+  // Read request body
+  if body, err = ioutil.ReadAll(rq.Body); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Unmarshal to type
+  if err = json.Unmarshal(body, &confirm); nil != err {
+    return fail(err, http.StatusBadRequest)
+  }
+
+  if len(confirm.NewPassphrase) < MinPassphraseLength {
+    return fail(fmt.Errorf("Passphrase must be at least %d characters", MinPassphraseLength),
+      http.StatusBadRequest)
+  }
+
+  sum := sha256.Sum256([]byte(confirm.Token))
+  tokenHash := hex.EncodeToString(sum[:])
+
+  // Resolve the token to its owning user, rejecting used or expired tokens
+  q := fmt.Sprintf("SELECT user_id FROM %s " +
+                   "WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?",
+                   c.Data.ResetTable)
+  if err = c.Service.Database.DB.QueryRow(q, tokenHash, time.Now().UTC()).Scan(&userID); nil != err {
+    c.Service.Auth.Penalise(ip)
+    return fail(fmt.Errorf("Invalid or expired token"), http.StatusUnauthorized)
+  }
+
+  algo, salt, hash, err := auth.Hash(confirm.NewPassphrase)
+  if nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Rewrite the credential and mark the token used atomically
+  updateCredential := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+    q := fmt.Sprintf("UPDATE %s SET hash = ?, salt = ?, algo = ? WHERE user_id = ?",
+      c.Data.CredentialTable)
+    return t.ExecContext(c.Service.Database.Context, q, hash, salt, algo, userID)
+  }
+
+  markUsed := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+    q := fmt.Sprintf("UPDATE %s SET used_at = ? WHERE token_hash = ?", c.Data.ResetTable)
+    return t.ExecContext(c.Service.Database.Context, q, time.Now().UTC(), tokenHash)
+  }
+
+  if _, err = c.Service.Database.Transaction(updateCredential, markUsed); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  c.Service.Auth.NoPenalty(ip)
+  re.Status = http.StatusOK
+  return nil
+}
+
+func (c *ConfirmController) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *ConfirmController) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
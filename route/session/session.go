@@ -0,0 +1,130 @@
+// Package session exposes the caller's own active login sessions.
+package session
+
+import (
+  "context"
+  "micrified.com/internal/user"
+  "micrified.com/route"
+  "net/http"
+  "time"
+)
+
+
+/*\
+ *******************************************************************************
+ *                              Type Definitions                               *
+ *******************************************************************************
+\*/
+
+
+// Data: Session
+type sessionData struct {
+  TimeFormat string
+}
+
+// Controller: Session
+type Controller route.ControllerType[sessionData]
+
+
+/*\
+ *******************************************************************************
+ *                                Constructors                                 *
+ *******************************************************************************
+\*/
+
+
+func NewController (s route.Service) Controller {
+  return Controller {
+    Name:    "sessions",
+    Methods: map[string]route.Method {
+      http.MethodGet: route.Restful.Get,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data: sessionData {
+      TimeFormat: "2006-01-02 15:04:05",
+    },
+  }
+}
+
+
+/*\
+ *******************************************************************************
+ *                            Interface: Controller                            *
+ *******************************************************************************
+\*/
+
+
+func (c *Controller) Route () string {
+  return "/" + c.Name
+}
+
+func (c *Controller) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *Controller) Timeout () time.Duration {
+  return c.Limit
+}
+
+
+/*\
+ *******************************************************************************
+ *                             Interface: Restful                              *
+ *******************************************************************************
+\*/
+
+
+type SessionEntry struct {
+  Expiration string `json:"expiration"`
+  IP         string `json:"ip"`
+}
+
+func (c *Controller) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  var (
+    ip       string = x.Value(user.UserIPKey).(string)
+    username string = rq.URL.Query().Get("username")
+    secret   string = rq.URL.Query().Get("secret")
+  )
+
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  // Check if authorized
+  if err := c.Service.Auth.Authorized(ip, username, secret); nil != err {
+    return fail(err, http.StatusUnauthorized)
+  }
+
+  // Fetch the caller's own live sessions
+  sessions, err := c.Service.Auth.Sessions(username)
+  if nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  list := make([]SessionEntry, 0, len(sessions))
+  for _, s := range sessions {
+    list = append(list, SessionEntry {
+      Expiration: s.Expiration.Format(c.Data.TimeFormat),
+      IP:         s.IP,
+    })
+  }
+
+  return re.Marshal(route.ContentTypeJSON, &list)
+}
+
+func (c *Controller) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *Controller) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *Controller) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
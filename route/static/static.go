@@ -5,11 +5,17 @@ package static
 
 import (
   "context"
+  "database/sql"
+  "encoding/json"
   "fmt"
-  "net/http"
+  "io/ioutil"
+  "micrified.com/internal/user"
   "micrified.com/route"
   "micrified.com/service/auth"
   "micrified.com/service/database"
+  "micrified.com/service/metrics"
+  "net/http"
+  "strconv"
   "time"
 )
 
@@ -41,6 +47,9 @@ type staticDataType struct {
 // Controller: Static
 type Controller route.ControllerType[staticDataType]
 
+// Controller: Static Revisions
+type RevisionsController route.ControllerType[staticDataType]
+
 
 /*\
  *******************************************************************************
@@ -64,7 +73,42 @@ var staticData staticDataType = staticDataType {
 
 
 func NewController (s route.Service) Controller {
+  ensureRevisionColumns(s)
+  ensureDeletedAtColumn(s)
+
   return Controller {
+    Name:    RouteName,
+    Methods: map[string]route.Method {
+      http.MethodGet:    route.Restful.Get,
+      http.MethodPut:    route.Restful.Put,
+      http.MethodDelete: route.Restful.Delete,
+    },
+    Service: s,
+    Limit:   5 * time.Second,
+    Data:    staticData,
+  }
+}
+
+// ensureRevisionColumns adds the page_id/revision columns the first time
+// this controller is constructed against a database that predates
+// page-content revisioning; it is a no-op on every startup after that.
+func ensureRevisionColumns (s route.Service) {
+  s.Database.DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS page_id INT NULL",
+    staticData.ContentTable))
+  s.Database.DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS revision INT NOT NULL DEFAULT 0",
+    staticData.ContentTable))
+}
+
+// ensureDeletedAtColumn adds the soft-delete column the first time this
+// controller is constructed against a database that predates it; it is a
+// no-op on every startup after that.
+func ensureDeletedAtColumn (s route.Service) {
+  s.Database.DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS deleted_at DATETIME NULL",
+    staticData.IndexTable))
+}
+
+func NewRevisionsController (s route.Service) RevisionsController {
+  return RevisionsController {
     Name:    RouteName,
     Methods: map[string]route.Method {
       http.MethodGet: route.Restful.Get,
@@ -83,6 +127,8 @@ func NewController (s route.Service) Controller {
 \*/
 
 
+// Controller
+
 func (c *Controller) Route () string {
   return "/" + c.Name + "/{name}"
 }
@@ -99,6 +145,24 @@ func (c *Controller) Timeout () time.Duration {
 }
 
 
+// RevisionsController
+
+func (c *RevisionsController) Route () string {
+  return "/" + c.Name + "/{name}/revisions"
+}
+
+func (c *RevisionsController) Handler (s string) route.Method {
+  if method, ok := c.Methods[s]; ok {
+    return method
+  }
+  return nil
+}
+
+func (c *RevisionsController) Timeout () time.Duration {
+  return c.Limit
+}
+
+
 /*\
  *******************************************************************************
  *                             Interface: Restful                              *
@@ -116,6 +180,9 @@ func (c *Controller) Get (x context.Context, rq *http.Request, re *route.Result)
   var (
     page GetResponse = GetResponse{}
     name string      = rq.PathValue("name")
+    rev  string      = rq.URL.Query().Get("rev")
+    args []any       = []any{name}
+    q    string
     err  error       = nil
   )
 
@@ -124,14 +191,30 @@ func (c *Controller) Get (x context.Context, rq *http.Request, re *route.Result)
     return err
   }
 
-  q := fmt.Sprintf("SELECT a.body, a.created, a.updated FROM %s AS a " +
-                   "INNER JOIN %s AS b " +
-		   "ON a.id = b.content_id " +
-		   "WHERE b.url_hash = unhex(md5(?))",
-		   c.Data.ContentTable, c.Data.IndexTable)
+  // Without a revision, resolve through the index row's current content_id
+  q = fmt.Sprintf("SELECT a.body, a.created, a.updated FROM %s AS a " +
+                  "INNER JOIN %s AS b " +
+		  "ON a.id = b.content_id " +
+		  "WHERE b.url_hash = unhex(md5(?)) AND b.deleted_at IS NULL",
+		  c.Data.ContentTable, c.Data.IndexTable)
+
+  // An explicit ?rev=N pins the lookup to a historical revision instead
+  if "" != rev {
+    revision, convErr := strconv.Atoi(rev)
+    if nil != convErr {
+      return fail(fmt.Errorf("Invalid revision"), http.StatusBadRequest)
+    }
+    q = fmt.Sprintf("SELECT a.body, a.created, a.updated FROM %s AS a " +
+                    "INNER JOIN %s AS b " +
+		    "ON a.page_id = b.id " +
+		    "WHERE b.url_hash = unhex(md5(?)) AND b.deleted_at IS NULL " +
+		    "AND a.revision = ?",
+		    c.Data.ContentTable, c.Data.IndexTable)
+    args = append(args, revision)
+  }
 
   // Extract row
-  rows, err := c.Service.Database.DB.Query(q, name)
+  rows, err := c.Service.Database.DB.Query(q, args...)
   if nil != err {
     return fail(err, http.StatusInternalServerError)
   }
@@ -139,6 +222,7 @@ func (c *Controller) Get (x context.Context, rq *http.Request, re *route.Result)
 
   // Verify entry exists
   if !rows.Next() {
+    metrics.Incr("static.get.miss")
     return fail(fmt.Errorf("Page %s not found", name), http.StatusNotFound)
   }
 
@@ -147,6 +231,7 @@ func (c *Controller) Get (x context.Context, rq *http.Request, re *route.Result)
     return fail(err, http.StatusInternalServerError)
   }
 
+  metrics.Incr("static.get.hit")
   return re.Marshal(route.ContentTypeJSON, &page)
 }
 
@@ -191,32 +276,49 @@ func (c *Controller) Post (x context.Context, rq *http.Request, re *route.Result
     return fail(err, http.StatusUnauthorized)
   }
 
+  var contentID int64
+
   // Define insert content
   insertBody := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
     q := fmt.Sprintf("INSERT INTO %s (created,updated,body) VALUES (?,?,?)",
       c.Data.ContentTable)
-    return t.ExecContext(c.Service.Database.Context, q, timeStamp, timeStamp,
+    r, err := t.ExecContext(c.Service.Database.Context, q, timeStamp, timeStamp,
       post.Data.Body)
+    if nil != err {
+      return nil, err
+    }
+    contentID, err = r.LastInsertId()
+    return r, err
   }
 
   // Define insert record
   insertRecord := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
-    id, err := lastResult.LastInsertId()
-    if nil != err {
-      return nil, err
-    }
     q := fmt.Sprintf("INSERT INTO %s (url_hash,content_id) " +
                      "VALUES (UNHEX(MD5(?)),?)",
       c.Data.IndexTable)
-    return t.ExecContext(c.Service.Database.Context, q, post.Data.Name, id)
+    return t.ExecContext(c.Service.Database.Context, q, post.Data.Name, contentID)
+  }
+
+  // Define repoint of the freshly inserted content row at its own index
+  // row, exactly like Put does for every later revision, so revision 0
+  // resolves through the same page_id join as everything after it
+  repointContent := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+    pageID, err := lastResult.LastInsertId()
+    if nil != err {
+      return nil, err
+    }
+    q := fmt.Sprintf("UPDATE %s SET page_id = ? WHERE id = ?", c.Data.ContentTable)
+    return t.ExecContext(c.Service.Database.Context, q, pageID, contentID)
   }
 
   // Execute sequenced insert operations; get back result
-  r, err := c.Service.Database.Transaction(insertBody, insertRecord)
+  r, err := c.Service.Database.Transaction(insertBody, insertRecord, repointContent)
   if nil != err {
     return fail(err, http.StatusInternalServerError)
   }
 
+  metrics.Incr("static.post")
+
   // Write to buffer and return any encoding error
   return re.Marshal(route.ContentTypeJSON,
     &StaticPostResponse {
@@ -227,11 +329,218 @@ func (c *Controller) Post (x context.Context, rq *http.Request, re *route.Result
   })
 }
 
+type StaticPut struct {
+  Body string `json:"body"`
+}
+
+type StaticPutResponse struct {
+  Name     string `json:"name"`
+  Body     string `json:"body"`
+  Revision int    `json:"revision"`
+  Updated  string `json:"updated"`
+}
+
 func (c *Controller) Put (x context.Context, rq *http.Request, re *route.Result) error {
-  return re.Unimplemented()
+  var (
+    body      []byte                   = []byte{}
+    err       error                    = nil
+    ip        string                   = x.Value(user.UserIPKey).(string)
+    put       auth.AuthData[StaticPut] = auth.AuthData[StaticPut]{}
+    name      string                   = rq.PathValue("name")
+    timeStamp time.Time                = time.Now().UTC()
+    pageID    int64                    = -1
+    revision  int                      = 0
+  )
+
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  // Read request body
+  if body, err = ioutil.ReadAll(rq.Body); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Unmarshal to type
+  if err = json.Unmarshal(body, &put); nil != err {
+    return fail(err, http.StatusBadRequest)
+  }
+
+  // Check if authorized
+  if err = c.Service.Auth.Authorized(ip, put.Username, put.Secret); nil != err {
+    return fail(err, http.StatusUnauthorized)
+  }
+
+  // Resolve the index row and its highest known revision
+  q := fmt.Sprintf("SELECT b.id, COALESCE(MAX(a.revision), 0) FROM %s AS b " +
+                   "LEFT JOIN %s AS a ON a.page_id = b.id " +
+		   "WHERE b.url_hash = unhex(md5(?)) AND b.deleted_at IS NULL " +
+		   "GROUP BY b.id", c.Data.IndexTable, c.Data.ContentTable)
+  if err = c.Service.Database.DB.QueryRow(q, name).Scan(&pageID, &revision); nil != err {
+    if sql.ErrNoRows == err {
+      return fail(fmt.Errorf("Page %s not found", name), http.StatusNotFound)
+    }
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Define insert of the new revision
+  insertRevision := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+    q := fmt.Sprintf("INSERT INTO %s (page_id,revision,created,updated,body) " +
+                     "VALUES (?,?,?,?,?)", c.Data.ContentTable)
+    return t.ExecContext(c.Service.Database.Context, q, pageID, revision + 1,
+      timeStamp, timeStamp, put.Data.Body)
+  }
+
+  // Define repoint of the index row at the new revision
+  repointRecord := func (lastResult sql.Result, t *sql.Tx) (sql.Result, error) {
+    id, err := lastResult.LastInsertId()
+    if nil != err {
+      return nil, err
+    }
+    q := fmt.Sprintf("UPDATE %s SET content_id = ? WHERE id = ?", c.Data.IndexTable)
+    return t.ExecContext(c.Service.Database.Context, q, id, pageID)
+  }
+
+  // Execute sequenced insert + repoint inside a single transaction
+  if _, err = c.Service.Database.Transaction(insertRevision, repointRecord); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Write to buffer and return any encoding error
+  return re.Marshal(route.ContentTypeJSON,
+    &StaticPutResponse {
+      Name:     name,
+      Body:     put.Data.Body,
+      Revision: revision + 1,
+      Updated:  timeStamp.Format(c.Data.TimeFormat),
+    })
 }
 
+type StaticDelete struct{}
+
 func (c *Controller) Delete (x context.Context, rq *http.Request, re *route.Result) error {
+  var (
+    body []byte                      = []byte{}
+    err  error                       = nil
+    ip   string                      = x.Value(user.UserIPKey).(string)
+    del  auth.AuthData[StaticDelete] = auth.AuthData[StaticDelete]{}
+    name string                      = rq.PathValue("name")
+  )
+
+  fail := func (err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  // Read request body
+  if body, err = ioutil.ReadAll(rq.Body); nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Unmarshal to type
+  if err = json.Unmarshal(body, &del); nil != err {
+    return fail(err, http.StatusBadRequest)
+  }
+
+  // Check if authorized
+  if err = c.Service.Auth.Authorized(ip, del.Username, del.Secret); nil != err {
+    return fail(err, http.StatusUnauthorized)
+  }
+
+  // Define soft-delete of the index row; revisions are left intact
+  softDelete := func (lastResult sql.Result, conn *sql.Conn) (sql.Result, error) {
+    q := fmt.Sprintf("UPDATE %s SET deleted_at = ? " +
+                     "WHERE url_hash = unhex(md5(?)) AND deleted_at IS NULL",
+		     c.Data.IndexTable)
+    return conn.ExecContext(c.Service.Database.Context, q, time.Now().UTC(), name)
+  }
+
+  // Execute the soft-delete; get back result
+  r, err := c.Service.Database.Connection(softDelete)
+  if nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Verify the right number of rows were affected
+  n, err := r.RowsAffected()
+  if nil != err {
+    return fail(err, http.StatusInternalServerError)
+  } else if 0 == n {
+    return fail(fmt.Errorf("Page %s not found", name), http.StatusNotFound)
+  }
+
+  return nil
+}
+
+
+/*\
+ *******************************************************************************
+ *                       Interface: Restful (Revisions)                        *
+ *******************************************************************************
+\*/
+
+
+type RevisionEntry struct {
+  Revision int    `json:"revision"`
+  Created  string `json:"created"`
+}
+
+func (c *RevisionsController) Get (x context.Context, rq *http.Request, re *route.Result) error {
+  var (
+    name  string = rq.PathValue("name")
+    entry RevisionEntry
+    list  []RevisionEntry
+    err   error
+  )
+
+  fail := func(err error, status int) error {
+    re.Status = status
+    return err
+  }
+
+  q := fmt.Sprintf("SELECT a.revision, a.created FROM %s AS a " +
+                   "INNER JOIN %s AS b ON a.page_id = b.id " +
+		   "WHERE b.url_hash = unhex(md5(?)) AND b.deleted_at IS NULL " +
+		   "ORDER BY a.revision DESC", c.Data.ContentTable, c.Data.IndexTable)
+
+  // Extract rows
+  rows, err := c.Service.Database.DB.Query(q, name)
+  if nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+  defer rows.Close()
+
+  // Marshal rows
+  for rows.Next() {
+    if err = rows.Scan(&entry.Revision, &entry.Created); nil != err {
+      break
+    }
+    list = append(list, entry)
+  }
+
+  // Check error
+  if nil != err {
+    return fail(err, http.StatusInternalServerError)
+  }
+
+  // Verify the page exists
+  if nil == list {
+    return fail(fmt.Errorf("Page %s not found", name), http.StatusNotFound)
+  }
+
+  return re.Marshal(route.ContentTypeJSON, &list)
+}
+
+func (c *RevisionsController) Post (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *RevisionsController) Put (x context.Context, rq *http.Request, re *route.Result) error {
+  return re.Unimplemented()
+}
+
+func (c *RevisionsController) Delete (x context.Context, rq *http.Request, re *route.Result) error {
   return re.Unimplemented()
 }
 
@@ -0,0 +1,82 @@
+package auth
+
+import (
+  "context"
+  "database/sql"
+  "fmt"
+  "strings"
+)
+
+
+/*\
+ *******************************************************************************
+ *                              Type Definitions                               *
+ *******************************************************************************
+\*/
+
+
+// Client is a registered OAuth2 client, resolved by ClientStore and
+// compared against with the same Hash/Compare pair used for user
+// credentials.
+type Client struct {
+  ID           string
+  SecretHash   []byte
+  Salt         []byte
+  Algo         string
+  RedirectURIs []string
+  Scopes       []string
+}
+
+// ClientStore resolves registered OAuth2 clients by client_id, shaped the
+// way most OAuth2 server libraries expect their client store to look.
+type ClientStore struct {
+  DB    *sql.DB
+  Table string
+}
+
+
+/*\
+ *******************************************************************************
+ *                                Constructors                                 *
+ *******************************************************************************
+\*/
+
+
+func NewClientStore (db *sql.DB, table string) ClientStore {
+  return ClientStore {
+    DB:    db,
+    Table: table,
+  }
+}
+
+
+/*\
+ *******************************************************************************
+ *                                  Methods                                    *
+ *******************************************************************************
+\*/
+
+
+// GetByID looks up a client by its public identifier. Redirect URIs and
+// scopes are stored as comma-separated columns rather than in separate
+// join tables.
+func (s ClientStore) GetByID (ctx context.Context, id string) (Client, error) {
+  var (
+    client       Client = Client{ID: id}
+    redirectURIs string
+    scopes       string
+  )
+
+  q := fmt.Sprintf("SELECT client_secret_hash, salt, algo, redirect_uris, scopes " +
+                   "FROM %s WHERE client_id = ?", s.Table)
+
+  row := s.DB.QueryRowContext(ctx, q, id)
+  if err := row.Scan(&client.SecretHash, &client.Salt, &client.Algo, &redirectURIs,
+    &scopes); nil != err {
+    return Client{}, err
+  }
+
+  client.RedirectURIs = strings.Split(redirectURIs, ",")
+  client.Scopes = strings.Split(scopes, ",")
+  return client, nil
+}
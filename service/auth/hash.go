@@ -0,0 +1,144 @@
+package auth
+
+import (
+  "crypto/rand"
+  "crypto/subtle"
+  "fmt"
+  "golang.org/x/crypto/argon2"
+  "golang.org/x/crypto/scrypt"
+  "strconv"
+  "strings"
+)
+
+
+/*\
+ *******************************************************************************
+ *                                  Constants                                  *
+ *******************************************************************************
+\*/
+
+
+const (
+  Argon2KeyLength uint32 = 32
+  SaltLength      int    = 16
+)
+
+// Argon2id tuning parameters. These are vars rather than consts so service
+// config can override them at startup; changed values only affect freshly
+// hashed credentials; rows hashed under the old values keep working since
+// algo is read back from the stored row, not recomputed from these.
+var (
+  Argon2Time    uint32 = 3
+  Argon2Memory  uint32 = 64 * 1024
+  Argon2Threads uint8  = 2
+)
+
+// DefaultAlgo is written for every freshly hashed credential, and is the
+// target of transparent rehashing on login.
+func DefaultAlgo () string {
+  return fmt.Sprintf("argon2id-%d-%d-%d", Argon2Time, Argon2Memory, Argon2Threads)
+}
+
+// LegacyScrypt* are the scrypt parameters credentials were hashed with
+// before Argon2id was introduced. LegacyAlgo is what a migration backfills
+// onto pre-existing credential rows, so Compare still routes them through
+// compareScrypt instead of comparing Argon2id output against old hash bytes.
+const (
+  LegacyScryptN int = 16384
+  LegacyScryptR int = 8
+  LegacyScryptP int = 1
+)
+
+var LegacyAlgo string = fmt.Sprintf("scrypt-%d-%d-%d", LegacyScryptN, LegacyScryptR, LegacyScryptP)
+
+
+/*\
+ *******************************************************************************
+ *                                  Functions                                  *
+ *******************************************************************************
+\*/
+
+
+// Hash derives a fresh salt and hashes passphrase with the current default
+// algorithm, returning the algo identifier to store alongside the row.
+func Hash (passphrase string) (algo string, salt, hash []byte, err error) {
+  salt = make([]byte, SaltLength)
+  if _, err = rand.Read(salt); nil != err {
+    return "", nil, nil, err
+  }
+  hash = argon2.IDKey([]byte(passphrase), salt, Argon2Time, Argon2Memory, Argon2Threads,
+    Argon2KeyLength)
+  return DefaultAlgo(), salt, hash, nil
+}
+
+// Compare verifies passphrase against a stored salt/hash pair using whichever
+// algorithm produced it. Unknown algorithms are rejected rather than
+// silently falling back to a default.
+func Compare (passphrase, algo string, salt, hash []byte) bool {
+  switch {
+  case strings.HasPrefix(algo, "argon2id-"):
+    return compareArgon2id(passphrase, algo, salt, hash)
+  case strings.HasPrefix(algo, "scrypt-"):
+    return compareScrypt(passphrase, algo, salt, hash)
+  default:
+    return false
+  }
+}
+
+// NeedsRehash reports whether a credential hashed under algo should be
+// transparently upgraded to DefaultAlgo on next successful login.
+func NeedsRehash (algo string) bool {
+  return algo != DefaultAlgo()
+}
+
+func compareArgon2id (passphrase, algo string, salt, hash []byte) bool {
+  var (
+    t uint64
+    m uint64
+    p uint64
+  )
+
+  fields := strings.Split(algo, "-")
+  if 4 != len(fields) {
+    return false
+  }
+  if _, err := fmt.Sscanf(fields[1], "%d", &t); nil != err {
+    return false
+  }
+  if _, err := fmt.Sscanf(fields[2], "%d", &m); nil != err {
+    return false
+  }
+  if _, err := fmt.Sscanf(fields[3], "%d", &p); nil != err {
+    return false
+  }
+
+  candidate := argon2.IDKey([]byte(passphrase), salt, uint32(t), uint32(m), uint8(p),
+    uint32(len(hash)))
+  return 1 == subtle.ConstantTimeCompare(candidate, hash)
+}
+
+func compareScrypt (passphrase, algo string, salt, hash []byte) bool {
+  fields := strings.Split(algo, "-")
+  if 4 != len(fields) {
+    return false
+  }
+
+  n, err := strconv.Atoi(fields[1])
+  if nil != err {
+    return false
+  }
+  r, err := strconv.Atoi(fields[2])
+  if nil != err {
+    return false
+  }
+  p, err := strconv.Atoi(fields[3])
+  if nil != err {
+    return false
+  }
+
+  candidate, err := scrypt.Key([]byte(passphrase), salt, n, r, p, len(hash))
+  if nil != err {
+    return false
+  }
+  return 1 == subtle.ConstantTimeCompare(candidate, hash)
+}
@@ -0,0 +1,326 @@
+package auth
+
+import (
+  "crypto/rand"
+  "encoding/binary"
+  "encoding/hex"
+  "fmt"
+  "io"
+  "os"
+  "sync"
+  "time"
+)
+
+
+/*\
+ *******************************************************************************
+ *                                  Constants                                  *
+ *******************************************************************************
+\*/
+
+
+// SecretLength is the number of random bytes making up a session secret.
+const SecretLength int = 32
+
+// Checkpoint format markers. checkpointVersion is bumped whenever the
+// on-disk record layout changes, so older checkpoints can still be read
+// (or rejected outright) instead of silently misparsed.
+const (
+  checkpointMagic   uint32 = 0x53455353 // "SESS"
+  checkpointVersion uint32 = 1
+)
+
+
+/*\
+ *******************************************************************************
+ *                              Type Definitions                               *
+ *******************************************************************************
+\*/
+
+
+// Secret is a random, hex-printable session token.
+type Secret []byte
+
+// Session is one issued login/bearer session.
+type Session struct {
+  Secret     Secret
+  Username   string
+  Expiration time.Time
+  IP         string
+
+  // LastAccess is maintained in memory only; it is not part of the
+  // checkpoint format yet and is reset to the load time on restore.
+  LastAccess time.Time
+}
+
+// SessionPool is the in-memory session store backing the Auth service. It
+// can be checkpointed to disk so a restart does not log every user out.
+type SessionPool struct {
+  mutex    sync.RWMutex
+  sessions map[string]Session
+}
+
+
+/*\
+ *******************************************************************************
+ *                                Constructors                                 *
+ *******************************************************************************
+\*/
+
+
+func NewSessionPool () *SessionPool {
+  return &SessionPool {
+    sessions: make(map[string]Session),
+  }
+}
+
+// NewSecret draws a fresh random session secret.
+func NewSecret () (Secret, error) {
+  b := make([]byte, SecretLength)
+  if _, err := rand.Read(b); nil != err {
+    return nil, err
+  }
+  return Secret(b), nil
+}
+
+
+/*\
+ *******************************************************************************
+ *                                  Methods                                    *
+ *******************************************************************************
+\*/
+
+
+// HexString renders the secret the way it is handed to callers and used as
+// the pool's map key; checkpoints store the raw secret bytes instead.
+func (s Secret) HexString () string {
+  return hex.EncodeToString(s)
+}
+
+// Put inserts or replaces a session, stamping LastAccess to now.
+func (p *SessionPool) Put (s Session) {
+  p.mutex.Lock()
+  defer p.mutex.Unlock()
+
+  s.LastAccess = time.Now().UTC()
+  p.sessions[s.Secret.HexString()] = s
+}
+
+// Get looks up a session by its hex secret and reports whether it exists.
+func (p *SessionPool) Get (secret string) (Session, bool) {
+  p.mutex.RLock()
+  defer p.mutex.RUnlock()
+
+  s, ok := p.sessions[secret]
+  return s, ok
+}
+
+// Touch refreshes a session's LastAccess without affecting Expiration.
+func (p *SessionPool) Touch (secret string) {
+  p.mutex.Lock()
+  defer p.mutex.Unlock()
+
+  if s, ok := p.sessions[secret]; ok {
+    s.LastAccess = time.Now().UTC()
+    p.sessions[secret] = s
+  }
+}
+
+// EvictIdle drops sessions that have not been accessed within maxIdle,
+// independently of their hard Expiration, and reports how many were
+// removed.
+func (p *SessionPool) EvictIdle (maxIdle time.Duration) int {
+  p.mutex.Lock()
+  defer p.mutex.Unlock()
+
+  now := time.Now().UTC()
+  evicted := 0
+  for k, s := range p.sessions {
+    if now.Sub(s.LastAccess) > maxIdle {
+      delete(p.sessions, k)
+      evicted++
+    }
+  }
+  return evicted
+}
+
+// Serialize writes every session as a length-prefixed binary record,
+// behind a magic/version header so future format changes stay detectable:
+//
+//   [magic uint32][version uint32][count uint32]
+//   n * ( [secretLen uint32][secret]
+//         [usernameLen uint32][username]
+//         [expirationLen uint32][expiration RFC3339]
+//         [ipLen uint32][ip] )
+func (p *SessionPool) Serialize (w io.Writer) error {
+  p.mutex.RLock()
+  defer p.mutex.RUnlock()
+
+  if err := binary.Write(w, binary.BigEndian, checkpointMagic); nil != err {
+    return err
+  }
+  if err := binary.Write(w, binary.BigEndian, checkpointVersion); nil != err {
+    return err
+  }
+  if err := binary.Write(w, binary.BigEndian, uint32(len(p.sessions))); nil != err {
+    return err
+  }
+
+  writeField := func (b []byte) error {
+    if err := binary.Write(w, binary.BigEndian, uint32(len(b))); nil != err {
+      return err
+    }
+    _, err := w.Write(b)
+    return err
+  }
+
+  for _, s := range p.sessions {
+    if err := writeField(s.Secret); nil != err {
+      return err
+    }
+    if err := writeField([]byte(s.Username)); nil != err {
+      return err
+    }
+    if err := writeField([]byte(s.Expiration.UTC().Format(time.RFC3339))); nil != err {
+      return err
+    }
+    if err := writeField([]byte(s.IP)); nil != err {
+      return err
+    }
+  }
+
+  return nil
+}
+
+// Deserialize replaces the pool's contents with the records read from r.
+// Restored sessions get LastAccess set to the load time, since idle
+// tracking is not yet part of the on-disk format.
+func (p *SessionPool) Deserialize (r io.Reader) error {
+  var (
+    magic, version, count uint32
+  )
+
+  if err := binary.Read(r, binary.BigEndian, &magic); nil != err {
+    return err
+  }
+  if checkpointMagic != magic {
+    return fmt.Errorf("Invalid session checkpoint (bad magic)")
+  }
+  if err := binary.Read(r, binary.BigEndian, &version); nil != err {
+    return err
+  }
+  if checkpointVersion != version {
+    return fmt.Errorf("Unsupported session checkpoint version %d", version)
+  }
+  if err := binary.Read(r, binary.BigEndian, &count); nil != err {
+    return err
+  }
+
+  readField := func () ([]byte, error) {
+    var n uint32
+    if err := binary.Read(r, binary.BigEndian, &n); nil != err {
+      return nil, err
+    }
+    b := make([]byte, n)
+    if _, err := io.ReadFull(r, b); nil != err {
+      return nil, err
+    }
+    return b, nil
+  }
+
+  now := time.Now().UTC()
+  sessions := make(map[string]Session, count)
+
+  for i := uint32(0); i < count; i++ {
+    secret, err := readField()
+    if nil != err {
+      return err
+    }
+    username, err := readField()
+    if nil != err {
+      return err
+    }
+    expirationText, err := readField()
+    if nil != err {
+      return err
+    }
+    ip, err := readField()
+    if nil != err {
+      return err
+    }
+
+    expiration, err := time.Parse(time.RFC3339, string(expirationText))
+    if nil != err {
+      return err
+    }
+
+    session := Session {
+      Secret:     Secret(secret),
+      Username:   string(username),
+      Expiration: expiration,
+      IP:         string(ip),
+      LastAccess: now,
+    }
+    sessions[session.Secret.HexString()] = session
+  }
+
+  p.mutex.Lock()
+  p.sessions = sessions
+  p.mutex.Unlock()
+
+  return nil
+}
+
+// Checkpoint atomically writes the pool to path: it serializes to
+// "path.tmp" and renames over path, so a crash mid-write never corrupts
+// the previous checkpoint.
+func (p *SessionPool) Checkpoint (path string) error {
+  tmp := path + ".tmp"
+
+  f, err := os.Create(tmp)
+  if nil != err {
+    return err
+  }
+
+  if err = p.Serialize(f); nil != err {
+    f.Close()
+    os.Remove(tmp)
+    return err
+  }
+  if err = f.Close(); nil != err {
+    os.Remove(tmp)
+    return err
+  }
+
+  return os.Rename(tmp, path)
+}
+
+// Load restores the pool from a previous Checkpoint. Call it once at
+// startup, before the HTTP listener binds.
+func (p *SessionPool) Load (path string) error {
+  f, err := os.Open(path)
+  if nil != err {
+    return err
+  }
+  defer f.Close()
+
+  return p.Deserialize(f)
+}
+
+// StartCheckpointing flushes the pool to path every interval until stop is
+// closed, so a crash loses at most one interval's worth of sessions.
+func (p *SessionPool) StartCheckpointing (path string, interval time.Duration, stop <-chan struct{}) {
+  ticker := time.NewTicker(interval)
+
+  go func () {
+    defer ticker.Stop()
+    for {
+      select {
+      case <-ticker.C:
+        p.Checkpoint(path)
+      case <-stop:
+        return
+      }
+    }
+  }()
+}
@@ -0,0 +1,62 @@
+// Package mail implements a minimal SMTP client used by services that need
+// to deliver transactional email (password resets, notifications, ...).
+package mail
+
+import (
+  "fmt"
+  "net/smtp"
+)
+
+
+/*\
+ *******************************************************************************
+ *                              Type Definitions                               *
+ *******************************************************************************
+\*/
+
+
+// Config: SMTP connection and sender details
+type Config struct {
+  Host, Port, Username, Password, From string
+}
+
+// Service: Mail
+type Service struct {
+  Config Config
+}
+
+
+/*\
+ *******************************************************************************
+ *                                Constructors                                 *
+ *******************************************************************************
+\*/
+
+
+func NewService (cfg Config) Service {
+  return Service {
+    Config: cfg,
+  }
+}
+
+
+/*\
+ *******************************************************************************
+ *                                  Methods                                    *
+ *******************************************************************************
+\*/
+
+
+// Send delivers a plain-text message to a single recipient.
+func (s Service) Send (to, subject, body string) error {
+  var auth smtp.Auth = nil
+
+  if "" != s.Config.Username {
+    auth = smtp.PlainAuth("", s.Config.Username, s.Config.Password, s.Config.Host)
+  }
+
+  addr := fmt.Sprintf("%s:%s", s.Config.Host, s.Config.Port)
+  msg  := []byte(fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", to, subject, body))
+
+  return smtp.SendMail(addr, auth, s.Config.From, []string{to}, msg)
+}
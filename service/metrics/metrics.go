@@ -0,0 +1,146 @@
+// Package metrics publishes operational counters and per-route latency
+// histograms over expvar. Importing this package is enough to expose them
+// at /debug/vars, since expvar registers that handler on
+// http.DefaultServeMux itself.
+package metrics
+
+import (
+  "context"
+  "encoding/json"
+  "expvar"
+  "micrified.com/route"
+  "net/http"
+  "sort"
+  "strconv"
+  "sync"
+  "time"
+)
+
+
+/*\
+ *******************************************************************************
+ *                                  Constants                                  *
+ *******************************************************************************
+\*/
+
+
+// latencyBucketsMS are the upper bounds (in milliseconds) of the per-route
+// latency histogram buckets; the final bucket catches everything above the
+// last bound.
+var latencyBucketsMS []float64 = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+
+/*\
+ *******************************************************************************
+ *                              Global Variables                               *
+ *******************************************************************************
+\*/
+
+
+var (
+  counters  *expvar.Map = expvar.NewMap("counters")
+  latencies *expvar.Map = expvar.NewMap("latencies")
+
+  histogramsMutex sync.Mutex
+  histograms      map[string]*Histogram = make(map[string]*Histogram)
+)
+
+
+/*\
+ *******************************************************************************
+ *                              Type Definitions                               *
+ *******************************************************************************
+\*/
+
+
+// Histogram counts latency observations into the fixed latencyBucketsMS
+// buckets. It implements expvar.Var so it can be registered directly into
+// the "latencies" map.
+type Histogram struct {
+  mutex  sync.Mutex
+  counts []int64
+}
+
+
+/*\
+ *******************************************************************************
+ *                                Constructors                                 *
+ *******************************************************************************
+\*/
+
+
+func newHistogram () *Histogram {
+  return &Histogram {
+    counts: make([]int64, len(latencyBucketsMS) + 1),
+  }
+}
+
+
+/*\
+ *******************************************************************************
+ *                                  Methods                                    *
+ *******************************************************************************
+\*/
+
+
+// Observe records one latency sample into the matching bucket.
+func (h *Histogram) Observe (d time.Duration) {
+  ms := float64(d) / float64(time.Millisecond)
+  idx := sort.SearchFloat64s(latencyBucketsMS, ms)
+
+  h.mutex.Lock()
+  h.counts[idx]++
+  h.mutex.Unlock()
+}
+
+// String renders the histogram as a JSON object of bucket label to count,
+// satisfying expvar.Var.
+func (h *Histogram) String () string {
+  h.mutex.Lock()
+  defer h.mutex.Unlock()
+
+  buckets := make(map[string]int64, len(h.counts))
+  for i, count := range h.counts {
+    label := "+Inf"
+    if i < len(latencyBucketsMS) {
+      label = strconv.FormatFloat(latencyBucketsMS[i], 'f', -1, 64)
+    }
+    buckets[label] = count
+  }
+
+  b, err := json.Marshal(buckets)
+  if nil != err {
+    return "{}"
+  }
+  return string(b)
+}
+
+// Incr bumps a named counter (e.g. "login.success", "static.get.hit") by one.
+func Incr (name string) {
+  counters.Add(name, 1)
+}
+
+func histogramFor (name string) *Histogram {
+  histogramsMutex.Lock()
+  defer histogramsMutex.Unlock()
+
+  h, ok := histograms[name]
+  if !ok {
+    h = newHistogram()
+    histograms[name] = h
+    latencies.Set(name, h)
+  }
+  return h
+}
+
+// Wrap instruments method with a latency histogram tagged by
+// controllerName, which callers pull from ControllerType.Name when
+// registering each route, so new controllers get metrics for free.
+func Wrap (controllerName string, method route.Method) route.Method {
+  return func (x context.Context, rq *http.Request, re *route.Result) error {
+    start := time.Now()
+    err := method(x, rq, re)
+    histogramFor(controllerName).Observe(time.Since(start))
+    return err
+  }
+}